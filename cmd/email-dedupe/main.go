@@ -0,0 +1,188 @@
+// Command email-dedupe reports accounts whose emails collapse to the same
+// normalized identity and, with -apply, backfills users.email_normalized for
+// the accounts that have no conflicts. It also works on databases where the
+// email_normalized migration has not been applied yet: that migration and the
+// server refuse to start until every reported group is merged.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/infrastructure/implementations/postgres"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type userRow struct {
+	ID               uuid.UUID
+	Email            string
+	StoredNormalized sql.NullString
+	Confirmed        bool
+	CreatedAt        time.Time
+}
+
+func main() {
+	apply := flag.Bool("apply", false, "update email_normalized for accounts without duplicates")
+	flag.Parse()
+
+	cfg := config.Init()
+	ctx := context.Background()
+
+	db, err := config.CreateDBConnection(cfg.DB.DBUrl())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		if err = db.Close(); err != nil {
+			log.Printf("Error closing database connection: %v", err)
+		}
+	}()
+
+	migrated, err := hasNormalizedColumn(ctx, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rows, err := loadUsers(ctx, db, migrated)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	groups := make(map[string][]userRow)
+	for _, row := range rows {
+		key := cfg.Email.Normalize(row.Email)
+		groups[key] = append(groups[key], row)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var duplicates int
+	var updates []userRow
+	var normalized []string
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) > 1 {
+			duplicates++
+			fmt.Printf("%s: %d accounts\n", key, len(group))
+			for _, row := range group {
+				fmt.Printf("  %s  %-40s confirmed=%t created=%s normalized=%s\n",
+					row.ID, row.Email, row.Confirmed, row.CreatedAt.Format(time.RFC3339), row.StoredNormalized.String)
+			}
+			continue
+		}
+		if row := group[0]; row.StoredNormalized.String != key {
+			updates = append(updates, row)
+			normalized = append(normalized, key)
+		}
+	}
+
+	fmt.Printf("%d users, %d duplicate groups, %d accounts to backfill\n", len(rows), duplicates, len(updates))
+	if !*apply || len(updates) == 0 {
+		return
+	}
+	if !migrated {
+		fmt.Println("users.email_normalized does not exist yet, merge the duplicates and start the server to run migrations")
+		return
+	}
+
+	if err = backfill(ctx, db, updates, normalized); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Backfilled %d accounts\n", len(updates))
+}
+
+func hasNormalizedColumn(ctx context.Context, db *sql.DB) (bool, error) {
+	query, args, err := postgres.Psql.
+		Select("1").
+		From("information_schema.columns").
+		Where(squirrel.Eq{"table_name": "users", "column_name": "email_normalized"}).
+		Where("table_schema = current_schema()").
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var exists int
+	err = db.QueryRowContext(ctx, query, args...).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func loadUsers(ctx context.Context, db *sql.DB, migrated bool) ([]userRow, error) {
+	normalizedColumn := "email_normalized"
+	if !migrated {
+		normalizedColumn = "NULL"
+	}
+
+	query, args, err := postgres.Psql.
+		Select("id", "email", normalizedColumn, "email_confirmed_at IS NOT NULL", "created_at").
+		From("users").
+		OrderBy("created_at", "id").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []userRow
+	for rows.Next() {
+		var row userRow
+		if err = rows.Scan(&row.ID, &row.Email, &row.StoredNormalized, &row.Confirmed, &row.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, row)
+	}
+
+	return users, rows.Err()
+}
+
+func backfill(ctx context.Context, db *sql.DB, updates []userRow, normalized []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Error rolling back email backfill: %v", err)
+		}
+	}()
+
+	for i, row := range updates {
+		query, args, err := postgres.Psql.
+			Update("users").
+			Set("email_normalized", normalized[i]).
+			Where(squirrel.Eq{"id": row.ID}).
+			ToSql()
+
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("backfill %s: %w", row.Email, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,10 +15,12 @@ import (
 	"authService/internal/config"
 	httpServe "authService/internal/infrastructure/http"
 	"authService/internal/infrastructure/implementations/broker"
+	"authService/internal/infrastructure/implementations/memory"
 	"authService/internal/infrastructure/implementations/postgres"
 	"authService/internal/infrastructure/middleware"
 	"authService/internal/monitoring"
 	"authService/internal/service"
+	"authService/internal/utils/password_policy"
 	"google.golang.org/grpc"
 )
 
@@ -50,13 +54,119 @@ func main() {
 		}
 	}()
 
+	if err = cfg.JWT.ValidateIssuer(); err != nil {
+		log.Fatal(err)
+	}
+	if err = cfg.HTTP.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	keyring, err := cfg.JWT.Keyring()
+	if err != nil {
+		log.Fatal(err)
+	}
+	signingKey := keyring.SigningKey()
+	log.Printf("JWT signing key loaded: kid=%s alg=%s", signingKey.ID, signingKey.Algorithm)
+	if cfg.JWT.KeysDir != "" {
+		go keyring.StartRotation(ctx, cfg.JWT.RotationInterval(), time.Minute)
+	}
+
+	passwordHasher, err := cfg.Password.Hasher(cfg.PasswordPolicy.MaxLength)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passwordPolicy, err := password_policy.New(cfg.PasswordPolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mfaSecretBox, err := cfg.MFA.SecretBox()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if mfaSecretBox == nil {
+		log.Println("MFA_ENCRYPTION_KEY is not set, TOTP enrollment is disabled")
+	}
+
+	relyingParty, err := cfg.WebAuthn.RelyingParty()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if relyingParty == nil {
+		log.Println("WEBAUTHN_RP_ID is not set, passkeys are disabled")
+	}
+
 	userRepository := postgres.NewUserRepositoryImpl(db)
+	backfilled, err := userRepository.BackfillNormalizedEmails(ctx, cfg.Email.Normalize)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if backfilled > 0 {
+		log.Printf("Backfilled normalized email for %d accounts", backfilled)
+	}
+
+	refreshTokenRepository := postgres.NewRefreshTokenRepositoryImpl(db)
+	denylistRepository := postgres.NewTokenDenylistRepositoryImpl(db)
+	if cfg.TokenDenylist == "memory" {
+		denylistRepository = memory.NewTokenDenylistRepositoryImpl()
+	}
+	sessionRepository := postgres.NewSessionRepositoryImpl(db)
+	verificationRepository := postgres.NewVerificationTokenRepositoryImpl(db)
+	passwordHistoryRepository := postgres.NewPasswordHistoryRepositoryImpl(db)
+	loginAttemptRepository := postgres.NewLoginAttemptRepositoryImpl(db)
+	totpRepository := postgres.NewTOTPRepositoryImpl(db)
+	recoveryCodeRepository := postgres.NewRecoveryCodeRepositoryImpl(db)
+	passkeyRepository := postgres.NewPasskeyRepositoryImpl(db)
+	webAuthnChallengeRepository := postgres.NewWebAuthnChallengeRepositoryImpl(db)
+	oauthClientRepository := postgres.NewOAuthClientRepositoryImpl(db)
+	authorizationCodeRepository := postgres.NewAuthorizationCodeRepositoryImpl(db)
+	go service.StartTokenCleanup(
+		ctx,
+		denylistRepository,
+		verificationRepository,
+		loginAttemptRepository,
+		webAuthnChallengeRepository,
+		authorizationCodeRepository,
+		cfg.Lockout.Window(),
+		time.Hour,
+	)
+
 	brokerRepo := broker.NewRabbitRepositoryImpl(cfg)
-	userService := service.NewUserService(userRepository, brokerRepo)
+	userService := service.NewUserService(
+		userRepository,
+		brokerRepo,
+		refreshTokenRepository,
+		denylistRepository,
+		sessionRepository,
+		verificationRepository,
+		passwordHistoryRepository,
+		loginAttemptRepository,
+		totpRepository,
+		recoveryCodeRepository,
+		passkeyRepository,
+		webAuthnChallengeRepository,
+		oauthClientRepository,
+		authorizationCodeRepository,
+		keyring,
+		passwordHasher,
+		passwordPolicy,
+		mfaSecretBox,
+		relyingParty,
+	)
 	srv := httpServe.NewGRPCServer(userService, cfg)
 
 	api.RegisterAuthServiceServer(grpcServer, srv)
 
+	mux := http.NewServeMux()
+	httpServe.NewWellKnownHandler(keyring, cfg).Register(mux)
+	httpServe.NewAdminHandler(keyring, userService, cfg).Register(mux)
+	httpServe.NewOAuthHandler(userService, cfg).Register(mux)
+	httpServer := &http.Server{
+		Addr:              ":" + cfg.HTTP.Port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
 	go monitoring.StartMetricsServer(cfg.MetricsPort)
 
 	listener, err := net.Listen("tcp", ":8081")
@@ -72,15 +182,37 @@ func main() {
 		}
 	}()
 
+	httpErr := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.HTTP.TLS() {
+			log.Printf("HTTPS server starting on :%s", cfg.HTTP.Port)
+			err = httpServer.ListenAndServeTLS(cfg.HTTP.TLSCertFile, cfg.HTTP.TLSKeyFile)
+		} else {
+			log.Printf("HTTP server starting on :%s, TLS must be terminated by a proxy", cfg.HTTP.Port)
+			err = httpServer.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			httpErr <- err
+		}
+	}()
+
 	select {
 	case err = <-grpcErr:
 		log.Fatalf("gRPC server error: %v", err)
+	case err = <-httpErr:
+		log.Fatalf("HTTP server error: %v", err)
 	case <-ctx.Done():
 		log.Println("Received shutdown signal...")
 
-		_, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
+		log.Println("Stopping HTTP server gracefully...")
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP server shutdown error: %v", err)
+		}
+
 		log.Println("Stopping gRPC server gracefully...")
 		grpcServer.GracefulStop()
 
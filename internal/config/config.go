@@ -1,23 +1,46 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"authService/internal/utils"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/password_policy"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	JWT             JWTConfig
-	RabbitMQ        RabbitMQConfig
-	DB              DBConfig
-	Email           EmailConfig
-	MetricsPort     string
-	BrokerConstants struct {
-		EmailConfirm string
+	JWT                  JWTConfig
+	RabbitMQ             RabbitMQConfig
+	DB                   DBConfig
+	Email                EmailConfig
+	Password             PasswordConfig
+	PasswordPolicy       password_policy.Config
+	Lockout              LockoutConfig
+	MFA                  MFAConfig
+	WebAuthn             WebAuthnConfig
+	OAuth                OAuthConfig
+	HTTP                 HTTPConfig
+	MetricsPort          string
+	AdminToken           string
+	IntrospectionSecrets []string
+	TokenDenylist        string
+	RegisterHideExisting bool
+	BrokerConstants      struct {
+		EmailConfirm  string
+		PasswordReset string
+		LoginCode     string
+		AccountLocked string
+		AccountExists string
 	}
 }
 
@@ -26,6 +49,16 @@ type JWTConfig struct {
 	AccessExpireMinutes int
 	RefreshExpireDays   int
 	Algorithm           string
+	Issuer              string
+	Audiences           []string
+	KeyID               string
+	PrivateKey          string
+	PrivateKeyPath      string
+	RetiredSecretKeys   []string
+	RetiredKeyPaths     []string
+	KeysDir             string
+	RotationDays        int
+	PrepublishMinutes   int
 }
 
 type RabbitMQConfig struct {
@@ -43,9 +76,67 @@ type DBConfig struct {
 	DB       string
 }
 
+type PasswordConfig struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	Pepper            string
+	PepperVersion     int
+	RetiredPeppers    []string
+	HistorySize       int
+}
+
+type LockoutConfig struct {
+	Threshold       int
+	IPThreshold     int
+	BackoffSeconds  int
+	DurationMinutes int
+	WindowMinutes   int
+}
+
+type MFAConfig struct {
+	Issuer              string
+	EncryptionKey       string
+	ChallengeTTLSeconds int
+	TOTPSkew            int
+	MaxAttempts         int
+	RecoveryCodes       int
+}
+
+type WebAuthnConfig struct {
+	RPID           string
+	RPName         string
+	Origins        []string
+	TimeoutSeconds int
+}
+
+type OAuthConfig struct {
+	CodeTTLSeconds int
+	LoginClientID  string
+	LoginURL       string
+	ConsentURL     string
+}
+
+// HTTPConfig is the listener of the OAuth, discovery and admin endpoints.
+// Without a certificate it serves plain HTTP and must sit behind a
+// TLS-terminating proxy.
+type HTTPConfig struct {
+	Port        string
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
 type EmailConfig struct {
-	Sender      string
-	AppPassword string
+	Sender                 string
+	AppPassword            string
+	ConfirmExpireHours     int
+	ResetExpireMinutes     int
+	ResendCooldownSeconds  int
+	NormalizeProviders     bool
+	LoginCodeExpireMinutes int
+	LoginCodeMaxAttempts   int
 }
 
 func Init() *Config {
@@ -74,15 +165,105 @@ func Init() *Config {
 		RefreshExpireDays:   utils.Atoi(getEnv("REFRESH_TOKEN_EXPIRE_DAYS", "")),
 		JWTSecret:           getEnv("SECRET_KEY", ""),
 		Algorithm:           getEnv("ALGORITHM", ""),
+		Issuer:              getEnv("JWT_ISSUER", ""),
+		Audiences:           getEnvList("JWT_AUDIENCES"),
+		KeyID:               getEnv("JWT_KEY_ID", ""),
+		PrivateKey:          getEnv("JWT_PRIVATE_KEY", ""),
+		PrivateKeyPath:      getEnv("JWT_PRIVATE_KEY_PATH", ""),
+		RetiredSecretKeys:   getEnvList("JWT_RETIRED_SECRET_KEYS"),
+		RetiredKeyPaths:     getEnvList("JWT_RETIRED_KEY_PATHS"),
+		KeysDir:             getEnv("JWT_KEYS_DIR", ""),
+		RotationDays:        utils.Atoi(getEnv("JWT_ROTATION_DAYS", "0")),
+		PrepublishMinutes:   utils.Atoi(getEnv("JWT_KEY_PREPUBLISH_MINUTES", "10")),
 	}
 
 	config.Email = EmailConfig{
-		Sender:      getEnv("SENDER", ""),
-		AppPassword: getEnv("APP_PASSWORD", ""),
+		Sender:                 getEnv("SENDER", ""),
+		AppPassword:            getEnv("APP_PASSWORD", ""),
+		ConfirmExpireHours:     utils.Atoi(getEnv("EMAIL_CONFIRM_EXPIRE_HOURS", "24")),
+		ResetExpireMinutes:     utils.Atoi(getEnv("PASSWORD_RESET_EXPIRE_MINUTES", "30")),
+		ResendCooldownSeconds:  utils.Atoi(getEnv("EMAIL_RESEND_COOLDOWN_SECONDS", "60")),
+		NormalizeProviders:     getEnvBool("EMAIL_PROVIDER_NORMALIZATION", false),
+		LoginCodeExpireMinutes: utils.Atoi(getEnv("LOGIN_CODE_EXPIRE_MINUTES", "10")),
+		LoginCodeMaxAttempts:   utils.Atoi(getEnv("LOGIN_CODE_MAX_ATTEMPTS", "5")),
+	}
+
+	config.Password = PasswordConfig{
+		Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", hashing.AlgorithmArgon2id),
+		BcryptCost:        utils.Atoi(getEnv("PASSWORD_BCRYPT_COST", "10")),
+		Argon2Memory:      utils.Atoi(getEnv("PASSWORD_ARGON2_MEMORY_KB", "65536")),
+		Argon2Iterations:  utils.Atoi(getEnv("PASSWORD_ARGON2_ITERATIONS", "3")),
+		Argon2Parallelism: utils.Atoi(getEnv("PASSWORD_ARGON2_PARALLELISM", "2")),
+		Pepper:            getEnv("PASSWORD_PEPPER", ""),
+		PepperVersion:     utils.Atoi(getEnv("PASSWORD_PEPPER_VERSION", "1")),
+		RetiredPeppers:    getEnvList("PASSWORD_RETIRED_PEPPERS"),
+		HistorySize:       utils.Atoi(getEnv("PASSWORD_HISTORY_SIZE", "5")),
+	}
+
+	config.PasswordPolicy = password_policy.Config{
+		MinLength:     utils.Atoi(getEnv("PASSWORD_MIN_LENGTH", "8")),
+		MaxLength:     utils.Atoi(getEnv("PASSWORD_MAX_LENGTH", "128")),
+		RequireUpper:  getEnvBool("PASSWORD_REQUIRE_UPPER", false),
+		RequireLower:  getEnvBool("PASSWORD_REQUIRE_LOWER", false),
+		RequireDigit:  getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+		RequireSymbol: getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		RejectEmail:   getEnvBool("PASSWORD_REJECT_EMAIL", true),
+		RejectCommon:  getEnvBool("PASSWORD_REJECT_COMMON", true),
+		MinStrength:   utils.Atoi(getEnv("PASSWORD_MIN_STRENGTH", "2")),
+
+		BreachCheck:     getEnv("PASSWORD_BREACH_CHECK", ""),
+		BreachCorpusDir: getEnv("PASSWORD_BREACH_CORPUS_DIR", ""),
+		BreachAPIURL:    getEnv("PASSWORD_BREACH_API_URL", "https://api.pwnedpasswords.com/range/"),
+		BreachMinCount:  utils.Atoi(getEnv("PASSWORD_BREACH_MIN_COUNT", "1")),
+	}
+
+	config.Lockout = LockoutConfig{
+		Threshold:       utils.Atoi(getEnv("LOCKOUT_THRESHOLD", "5")),
+		IPThreshold:     utils.Atoi(getEnv("LOCKOUT_IP_THRESHOLD", "50")),
+		BackoffSeconds:  utils.Atoi(getEnv("LOCKOUT_BACKOFF_SECONDS", "1")),
+		DurationMinutes: utils.Atoi(getEnv("LOCKOUT_DURATION_MINUTES", "15")),
+		WindowMinutes:   utils.Atoi(getEnv("LOCKOUT_WINDOW_MINUTES", "15")),
+	}
+
+	config.MFA = MFAConfig{
+		Issuer:              getEnv("MFA_ISSUER", "authService"),
+		EncryptionKey:       getEnv("MFA_ENCRYPTION_KEY", ""),
+		ChallengeTTLSeconds: utils.Atoi(getEnv("MFA_CHALLENGE_TTL_SECONDS", "300")),
+		TOTPSkew:            utils.Atoi(getEnv("MFA_TOTP_SKEW", "1")),
+		MaxAttempts:         utils.Atoi(getEnv("MFA_MAX_ATTEMPTS", "5")),
+		RecoveryCodes:       utils.Atoi(getEnv("MFA_RECOVERY_CODES", "10")),
+	}
+
+	config.WebAuthn = WebAuthnConfig{
+		RPID:           getEnv("WEBAUTHN_RP_ID", ""),
+		RPName:         getEnv("WEBAUTHN_RP_NAME", "authService"),
+		Origins:        getEnvList("WEBAUTHN_ORIGINS"),
+		TimeoutSeconds: utils.Atoi(getEnv("WEBAUTHN_TIMEOUT_SECONDS", "300")),
+	}
+
+	config.OAuth = OAuthConfig{
+		CodeTTLSeconds: utils.Atoi(getEnv("OAUTH_CODE_TTL_SECONDS", "60")),
+		LoginClientID:  getEnv("OAUTH_LOGIN_CLIENT_ID", ""),
+		LoginURL:       getEnv("OAUTH_LOGIN_URL", ""),
+		ConsentURL:     getEnv("OAUTH_CONSENT_URL", ""),
+	}
+
+	config.HTTP = HTTPConfig{
+		Port:        getEnv("HTTP_PORT", "8080"),
+		TLSCertFile: getEnv("HTTP_TLS_CERT_FILE", ""),
+		TLSKeyFile:  getEnv("HTTP_TLS_KEY_FILE", ""),
 	}
 
 	config.MetricsPort = getEnv("METRICS_PORT", "")
+	config.AdminToken = getEnv("ADMIN_TOKEN", "")
+	config.IntrospectionSecrets = getEnvList("INTROSPECTION_SECRETS")
+	config.TokenDenylist = getEnv("TOKEN_DENYLIST", "postgres")
+	config.RegisterHideExisting = getEnvBool("REGISTER_HIDE_EXISTING", false)
 	config.BrokerConstants.EmailConfirm = "email-confirm"
+	config.BrokerConstants.PasswordReset = "password-reset"
+	config.BrokerConstants.LoginCode = "login-code"
+	config.BrokerConstants.AccountLocked = "account-locked"
+	config.BrokerConstants.AccountExists = "account-exists"
 
 	return config
 }
@@ -103,6 +284,283 @@ func (rabbitMQ RabbitMQConfig) RabbitMQUrl() string {
 	return fmt.Sprintf("amqp://%s:%s@%s:%d", rabbitMQ.User, rabbitMQ.Password, rabbitMQ.Host, port)
 }
 
+// Hasher builds the password hasher. maxLength is the policy's
+// PASSWORD_MAX_LENGTH: plain bcrypt cannot hash longer passwords, so that
+// combination is rejected unless a pepper is configured.
+func (password PasswordConfig) Hasher(maxLength int) (hashing.PasswordHasher, error) {
+	hashers, err := password.hashers()
+	if err != nil {
+		return nil, err
+	}
+	if password.Pepper == "" {
+		if password.Algorithm == hashing.AlgorithmBcrypt && (maxLength <= 0 || maxLength > hashing.BcryptMaxPasswordBytes) {
+			return nil, fmt.Errorf(
+				"bcrypt without PASSWORD_PEPPER accepts at most %d bytes: set PASSWORD_PEPPER or PASSWORD_MAX_LENGTH <= %d",
+				hashing.BcryptMaxPasswordBytes, hashing.BcryptMaxPasswordBytes,
+			)
+		}
+		return hashers, nil
+	}
+
+	retired := make([]hashing.Pepper, 0, len(password.RetiredPeppers))
+	for _, entry := range password.RetiredPeppers {
+		version, secret, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("retired pepper must be in version:secret format")
+		}
+		number, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retired pepper version %q: %w", version, err)
+		}
+		retired = append(retired, hashing.Pepper{Version: number, Secret: []byte(secret)})
+	}
+
+	return hashing.NewPepperedHasher(hashers, hashing.Pepper{
+		Version: password.PepperVersion,
+		Secret:  []byte(password.Pepper),
+	}, retired...)
+}
+
+func (password PasswordConfig) hashers() (*hashing.PasswordHashers, error) {
+	bcryptHasher, err := hashing.NewBcryptHasher(password.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Hasher, err := hashing.NewArgon2idHasher(hashing.Argon2Params{
+		Memory:      uint32(password.Argon2Memory),
+		Iterations:  uint32(password.Argon2Iterations),
+		Parallelism: uint8(password.Argon2Parallelism),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch password.Algorithm {
+	case hashing.AlgorithmArgon2id:
+		return hashing.NewPasswordHashers(argon2Hasher, bcryptHasher), nil
+	case hashing.AlgorithmBcrypt:
+		return hashing.NewPasswordHashers(bcryptHasher, argon2Hasher), nil
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm: %s", password.Algorithm)
+	}
+}
+
+func (lockout LockoutConfig) Duration() time.Duration {
+	return time.Minute * time.Duration(lockout.DurationMinutes)
+}
+
+func (lockout LockoutConfig) Window() time.Duration {
+	return time.Minute * time.Duration(lockout.WindowMinutes)
+}
+
+// Delay returns how long further attempts are blocked after the given number
+// of consecutive failures: nothing for the first failure, then an exponential
+// backoff capped at the lockout duration, and the full lockout once threshold
+// failures are reached.
+func (lockout LockoutConfig) Delay(failures int, threshold int) time.Duration {
+	if threshold <= 0 || failures < 2 {
+		return 0
+	}
+	if failures >= threshold {
+		return lockout.Duration()
+	}
+
+	delay := time.Second * time.Duration(lockout.BackoffSeconds)
+	for i := 2; i < failures && delay < lockout.Duration(); i++ {
+		delay *= 2
+	}
+	return min(delay, lockout.Duration())
+}
+
+func (email EmailConfig) ConfirmTTL() time.Duration {
+	return time.Hour * time.Duration(email.ConfirmExpireHours)
+}
+
+func (email EmailConfig) ResetTTL() time.Duration {
+	return time.Minute * time.Duration(email.ResetExpireMinutes)
+}
+
+func (email EmailConfig) LoginCodeTTL() time.Duration {
+	return time.Minute * time.Duration(email.LoginCodeExpireMinutes)
+}
+
+func (email EmailConfig) ResendCooldown() time.Duration {
+	return time.Second * time.Duration(email.ResendCooldownSeconds)
+}
+
+func (email EmailConfig) Normalize(address string) string {
+	return utils.NormalizeEmail(address, email.NormalizeProviders)
+}
+
+func (oauth OAuthConfig) CodeTTL() time.Duration {
+	return time.Second * time.Duration(oauth.CodeTTLSeconds)
+}
+
+func (mfa MFAConfig) ChallengeTTL() time.Duration {
+	return time.Second * time.Duration(mfa.ChallengeTTLSeconds)
+}
+
+// SecretBox returns nil when MFA_ENCRYPTION_KEY is not set, which disables
+// TOTP enrollment.
+func (mfa MFAConfig) SecretBox() (*hashing.SecretBox, error) {
+	if mfa.EncryptionKey == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(mfa.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MFA_ENCRYPTION_KEY: %w", err)
+	}
+
+	return hashing.NewSecretBox(key)
+}
+
+func (relyingParty WebAuthnConfig) Timeout() time.Duration {
+	return time.Second * time.Duration(relyingParty.TimeoutSeconds)
+}
+
+// RelyingParty returns nil when WEBAUTHN_RP_ID is not set, which disables passkeys.
+func (relyingParty WebAuthnConfig) RelyingParty() (*webauthn.WebAuthn, error) {
+	if relyingParty.RPID == "" {
+		return nil, nil
+	}
+
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    relyingParty.Timeout(),
+		TimeoutUVD: relyingParty.Timeout(),
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          relyingParty.RPID,
+		RPDisplayName: relyingParty.RPName,
+		RPOrigins:     relyingParty.Origins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+}
+
+func (jwtSettings JWTConfig) Keyring() (*hashing.Keyring, error) {
+	if jwtSettings.KeysDir != "" {
+		return hashing.LoadKeyring(jwtSettings.KeysDir, jwtSettings.Algorithm, jwtSettings.KeyRetention(), jwtSettings.KeyPrepublish())
+	}
+
+	active, err := jwtSettings.signingKey()
+	if err != nil {
+		return nil, err
+	}
+	keyring := hashing.NewKeyring(active, jwtSettings.KeyRetention())
+
+	for _, secret := range jwtSettings.RetiredSecretKeys {
+		key, err := hashing.NewHMACSigningKey("", []byte(secret))
+		if err != nil {
+			return nil, err
+		}
+		keyring.AddVerificationKey(key, time.Now())
+	}
+
+	for _, path := range jwtSettings.RetiredKeyPaths {
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read retired key: %w", err)
+		}
+		key, err := hashing.NewSigningKeyFromPEM("", "", pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		keyring.AddVerificationKey(key, time.Now())
+	}
+
+	return keyring, nil
+}
+
+func (httpSettings HTTPConfig) TLS() bool {
+	return httpSettings.TLSCertFile != ""
+}
+
+func (httpSettings HTTPConfig) Validate() error {
+	if httpSettings.Port == "" {
+		return fmt.Errorf("HTTP_PORT must not be empty")
+	}
+	if (httpSettings.TLSCertFile == "") != (httpSettings.TLSKeyFile == "") {
+		return fmt.Errorf("HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE must be set together")
+	}
+	return nil
+}
+
+// ValidateIssuer checks JWT_ISSUER, which discovery also uses as the base of
+// jwks_uri and the OAuth endpoint URLs. Plain http is allowed only for
+// loopback hosts in development.
+func (jwtSettings JWTConfig) ValidateIssuer() error {
+	if jwtSettings.Issuer == "" {
+		return nil
+	}
+
+	issuer, err := url.Parse(jwtSettings.Issuer)
+	if err != nil || !issuer.IsAbs() || issuer.Host == "" || issuer.User != nil ||
+		issuer.RawQuery != "" || issuer.ForceQuery || issuer.Fragment != "" {
+		return fmt.Errorf("JWT_ISSUER must be an absolute URL without query or fragment: %q", jwtSettings.Issuer)
+	}
+	if issuer.Scheme == "https" {
+		return nil
+	}
+	if issuer.Scheme == "http" && isLoopback(issuer.Hostname()) {
+		return nil
+	}
+	return fmt.Errorf("JWT_ISSUER must be an https URL: %q", jwtSettings.Issuer)
+}
+
+func (jwtSettings JWTConfig) AccessTTL() time.Duration {
+	return time.Minute * time.Duration(jwtSettings.AccessExpireMinutes)
+}
+
+func (jwtSettings JWTConfig) RefreshTTL() time.Duration {
+	return time.Hour * 24 * time.Duration(jwtSettings.RefreshExpireDays)
+}
+
+func (jwtSettings JWTConfig) KeyRetention() time.Duration {
+	return jwtSettings.RefreshTTL()
+}
+
+func (jwtSettings JWTConfig) KeyPrepublish() time.Duration {
+	return max(time.Minute*time.Duration(jwtSettings.PrepublishMinutes), hashing.JWKSMaxAge)
+}
+
+func (jwtSettings JWTConfig) RotationInterval() time.Duration {
+	return time.Hour * 24 * time.Duration(jwtSettings.RotationDays)
+}
+
+func (jwtSettings JWTConfig) signingKey() (*hashing.SigningKey, error) {
+	if jwtSettings.PrivateKey == "" && jwtSettings.PrivateKeyPath == "" {
+		if jwtSettings.Algorithm != hashing.AlgorithmHS256 {
+			return nil, hashing.AlgorithmNotAllowed
+		}
+		return hashing.NewHMACSigningKey(jwtSettings.KeyID, []byte(jwtSettings.JWTSecret))
+	}
+
+	pemBytes := []byte(jwtSettings.PrivateKey)
+	if jwtSettings.PrivateKeyPath != "" {
+		var err error
+		pemBytes, err = os.ReadFile(jwtSettings.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read private key: %w", err)
+		}
+	}
+
+	return hashing.NewSigningKeyFromPEM(jwtSettings.KeyID, jwtSettings.Algorithm, pemBytes)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func getEnv(key, defaultValue string) string {
 	value, exists := os.LookupEnv(key)
 	if !exists {
@@ -110,3 +568,32 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvList(key string) []string {
+	value := getEnv(key, "")
+	if value == "" {
+		return nil
+	}
+
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean value for %s: %q, using %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
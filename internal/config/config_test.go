@@ -0,0 +1,87 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateIssuer(t *testing.T) {
+	tests := []struct {
+		name    string
+		issuer  string
+		wantErr bool
+	}{
+		{name: "unset", issuer: "", wantErr: false},
+		{name: "https", issuer: "https://auth.example.com", wantErr: false},
+		{name: "https with path", issuer: "https://example.com/auth", wantErr: false},
+		{name: "http localhost", issuer: "http://localhost:2112", wantErr: false},
+		{name: "http loopback ip", issuer: "http://127.0.0.1:2112", wantErr: false},
+		{name: "http public host", issuer: "http://auth.example.com", wantErr: true},
+		{name: "plain string", issuer: "authService", wantErr: true},
+		{name: "no host", issuer: "https:///auth", wantErr: true},
+		{name: "query", issuer: "https://auth.example.com?tenant=1", wantErr: true},
+		{name: "fragment", issuer: "https://auth.example.com#top", wantErr: true},
+		{name: "userinfo", issuer: "https://user@auth.example.com", wantErr: true},
+		{name: "other scheme", issuer: "ftp://auth.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := JWTConfig{Issuer: tt.issuer}.ValidateIssuer()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIssuer(%q) error = %v, wantErr %v", tt.issuer, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLockoutDelay(t *testing.T) {
+	lockout := LockoutConfig{BackoffSeconds: 1, DurationMinutes: 15}
+	slowBackoff := LockoutConfig{BackoffSeconds: 600, DurationMinutes: 15}
+
+	tests := []struct {
+		name      string
+		lockout   LockoutConfig
+		failures  int
+		threshold int
+		want      time.Duration
+	}{
+		{name: "no failures", lockout: lockout, failures: 0, threshold: 5},
+		{name: "first failure", lockout: lockout, failures: 1, threshold: 5},
+		{name: "second failure", lockout: lockout, failures: 2, threshold: 5, want: time.Second},
+		{name: "third failure doubles", lockout: lockout, failures: 3, threshold: 5, want: 2 * time.Second},
+		{name: "fourth failure doubles", lockout: lockout, failures: 4, threshold: 5, want: 4 * time.Second},
+		{name: "threshold locks", lockout: lockout, failures: 5, threshold: 5, want: 15 * time.Minute},
+		{name: "past threshold stays locked", lockout: lockout, failures: 9, threshold: 5, want: 15 * time.Minute},
+		{name: "backoff capped at duration", lockout: slowBackoff, failures: 3, threshold: 10, want: 15 * time.Minute},
+		{name: "no backoff", lockout: LockoutConfig{DurationMinutes: 15}, failures: 4, threshold: 5},
+		{name: "lockout disabled", lockout: lockout, failures: 9, threshold: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lockout.Delay(tt.failures, tt.threshold); got != tt.want {
+				t.Errorf("Delay(%d, %d) = %v, want %v", tt.failures, tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		http    HTTPConfig
+		wantErr bool
+	}{
+		{name: "plain http behind a proxy", http: HTTPConfig{Port: "8080"}},
+		{name: "tls", http: HTTPConfig{Port: "8443", TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}},
+		{name: "no port", http: HTTPConfig{}, wantErr: true},
+		{name: "certificate without key", http: HTTPConfig{Port: "8443", TLSCertFile: "cert.pem"}, wantErr: true},
+		{name: "key without certificate", http: HTTPConfig{Port: "8443", TLSKeyFile: "key.pem"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.http.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
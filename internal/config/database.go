@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
@@ -28,9 +29,15 @@ func RunMigrations(dbUrl string) error {
 		return err
 	}
 
+	// Each migration file runs in a single transaction, so a failed one left
+	// no changes behind and is retried from the previous version.
 	if dirty {
-		log.Printf("Fixing dirty database version %d", version)
-		if err := migration.Force(int(version)); err != nil {
+		previous := int(version) - 1
+		if previous == 0 {
+			previous = database.NilVersion
+		}
+		log.Printf("Retrying failed migration %d", version)
+		if err := migration.Force(previous); err != nil {
 			return fmt.Errorf("force version failed: %w", err)
 		}
 	}
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+type OAuthClient struct {
+	ID           string
+	Name         string
+	SecretHash   []byte
+	RedirectURIs []string
+	GrantTypes   []string
+	Public       bool
+	FirstParty   bool
+	CreatedAt    time.Time
+}
+
+type AuthorizationCode struct {
+	CodeHash      []byte
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectURI   string
+	CodeChallenge string
+	SessionID     *uuid.UUID
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+}
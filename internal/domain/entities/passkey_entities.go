@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebAuthnPurposeRegistration = "registration"
+	WebAuthnPurposeLogin        = "login"
+)
+
+type PasskeyCredential struct {
+	ID              []byte
+	UserID          uuid.UUID
+	PublicKey       []byte
+	AttestationType string
+	Transports      []string
+	AAGUID          []byte
+	SignCount       uint32
+	BackupEligible  bool
+	BackupState     bool
+	CloneWarning    bool
+	CreatedAt       time.Time
+	LastUsedAt      *time.Time
+}
+
+type WebAuthnChallenge struct {
+	ID          uuid.UUID
+	UserID      *uuid.UUID
+	Purpose     string
+	SessionData []byte
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
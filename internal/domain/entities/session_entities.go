@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Session struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	UserAgent       string
+	IPAddress       string
+	CreatedAt       time.Time
+	LastRefreshedAt time.Time
+	RevokedAt       *time.Time
+}
@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshToken struct {
+	JTI       uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	SessionID *uuid.UUID
+	ParentJTI *uuid.UUID
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
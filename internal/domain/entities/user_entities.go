@@ -1 +1,17 @@
 package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID               uuid.UUID
+	Email            string
+	IsActive         bool
+	TokenVersion     int
+	EmailConfirmedAt *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
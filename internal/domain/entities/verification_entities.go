@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	VerificationPurposeEmailConfirm  = "email_confirm"
+	VerificationPurposePasswordReset = "password_reset"
+	VerificationPurposeLoginCode     = "login_code"
+	VerificationPurposeMagicLink     = "magic_link"
+)
+
+type VerificationToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   string
+	TokenHash []byte
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"authService/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+type AuthorizationCodeRepository interface {
+	InsertAuthorizationCode(ctx context.Context, code *entities.AuthorizationCode) error
+	GetAuthorizationCode(ctx context.Context, codeHash []byte) (*entities.AuthorizationCode, error)
+	ConsumeAuthorizationCode(ctx context.Context, codeHash []byte) (*entities.AuthorizationCode, error)
+	SetAuthorizationCodeSession(ctx context.Context, codeHash []byte, sessionID uuid.UUID) error
+	DeleteExpiredAuthorizationCodes(ctx context.Context) error
+}
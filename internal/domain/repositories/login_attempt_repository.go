@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"authService/internal/domain/entities"
+)
+
+type LoginAttemptRepository interface {
+	GetLoginAttempt(ctx context.Context, key string) (*entities.LoginAttempt, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	LockUntil(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	DeleteStaleAttempts(ctx context.Context, olderThan time.Duration) error
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"authService/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+type OAuthClientRepository interface {
+	InsertClient(ctx context.Context, client *entities.OAuthClient) error
+	GetClient(ctx context.Context, id string) (*entities.OAuthClient, error)
+	HasConsent(ctx context.Context, userID uuid.UUID, clientID string) (bool, error)
+	InsertConsent(ctx context.Context, userID uuid.UUID, clientID string) error
+}
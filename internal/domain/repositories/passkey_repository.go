@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"authService/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+type PasskeyRepository interface {
+	InsertPasskey(ctx context.Context, credential *entities.PasskeyCredential) error
+	GetPasskey(ctx context.Context, id []byte) (*entities.PasskeyCredential, error)
+	ListUserPasskeys(ctx context.Context, userID uuid.UUID) ([]entities.PasskeyCredential, error)
+	UpdatePasskeyUsage(ctx context.Context, id []byte, signCount uint32, backupState bool) error
+	FlagPasskeyClone(ctx context.Context, id []byte) error
+}
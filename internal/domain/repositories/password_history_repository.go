@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type PasswordHistoryRepository interface {
+	AddPasswordHistory(ctx context.Context, userID uuid.UUID, hashedPassword []byte) error
+	GetRecentPasswords(ctx context.Context, userID uuid.UUID, limit int) ([][]byte, error)
+	PrunePasswordHistory(ctx context.Context, userID uuid.UUID, keep int) error
+}
@@ -1,5 +1,7 @@
 package repositories
 
+import "authService/internal/domain/value_objects"
+
 type RabbitRepository interface {
-	CreateEmailMSG(email string) error
+	CreateEmailMSG(queue string, message value_objects.EmailMessage) error
 }
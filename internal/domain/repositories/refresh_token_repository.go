@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"authService/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepository interface {
+	InsertRefreshToken(ctx context.Context, token *entities.RefreshToken) error
+	GetRefreshToken(ctx context.Context, jti uuid.UUID) (*entities.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, usedJTI uuid.UUID, next *entities.RefreshToken) (bool, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID) error
+	RevokeSessionTokens(ctx context.Context, sessionID uuid.UUID) error
+	RevokeOtherSessionTokens(ctx context.Context, userID uuid.UUID, keepSessionID uuid.UUID) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"authService/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+type SessionRepository interface {
+	InsertSession(ctx context.Context, session *entities.Session) error
+	GetSession(ctx context.Context, id uuid.UUID) (*entities.Session, error)
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]entities.Session, error)
+	TouchSession(ctx context.Context, id uuid.UUID) error
+	RevokeSession(ctx context.Context, id uuid.UUID) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keepID uuid.UUID) error
+}
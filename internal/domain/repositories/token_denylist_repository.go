@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenDenylistRepository interface {
+	DenyToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error
+	IsTokenDenied(ctx context.Context, jti uuid.UUID) (bool, error)
+	DeleteExpiredTokens(ctx context.Context) error
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"authService/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+type TOTPRepository interface {
+	SavePendingTOTP(ctx context.Context, userID uuid.UUID, encryptedSecret []byte) (bool, error)
+	GetTOTP(ctx context.Context, userID uuid.UUID) (*entities.TOTPCredential, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+}
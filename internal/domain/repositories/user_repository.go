@@ -3,11 +3,19 @@ package repositories
 import (
 	"context"
 
+	"authService/internal/domain/entities"
 	"github.com/google/uuid"
 )
 
 type UserRepository interface {
-	InsertUser(ctx context.Context, email string, hashedPassword []byte) error
-	CheckUserExist(ctx context.Context, email string) (bool, error)
-	GetUserCredentials(ctx context.Context, email string) (uuid.UUID, []byte, error)
+	InsertUser(ctx context.Context, email string, normalizedEmail string, hashedPassword []byte) (uuid.UUID, error)
+	CheckUserExist(ctx context.Context, normalizedEmail string) (bool, error)
+	GetUserCredentials(ctx context.Context, normalizedEmail string) (uuid.UUID, []byte, error)
+	GetPasswordHash(ctx context.Context, id uuid.UUID) ([]byte, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*entities.User, error)
+	GetUserByEmail(ctx context.Context, normalizedEmail string) (*entities.User, error)
+	ConfirmEmail(ctx context.Context, id uuid.UUID) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword []byte) error
+	IncrementTokenVersion(ctx context.Context, id uuid.UUID) error
+	BackfillNormalizedEmails(ctx context.Context, normalize func(string) string) (int, error)
 }
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"authService/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+type VerificationTokenRepository interface {
+	InsertVerificationToken(ctx context.Context, token *entities.VerificationToken) error
+	GetActiveVerificationToken(ctx context.Context, purpose string, tokenHash []byte) (*entities.VerificationToken, error)
+	GetLatestActiveToken(ctx context.Context, userID uuid.UUID, purpose string) (*entities.VerificationToken, error)
+	ConsumeVerificationToken(ctx context.Context, purpose string, tokenHash []byte) (*entities.VerificationToken, error)
+	InvalidateUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error
+	LastIssuedAt(ctx context.Context, userID uuid.UUID, purpose string) (time.Time, error)
+	DeleteExpiredTokens(ctx context.Context) error
+}
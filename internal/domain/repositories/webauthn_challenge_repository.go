@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"authService/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+type WebAuthnChallengeRepository interface {
+	InsertChallenge(ctx context.Context, challenge *entities.WebAuthnChallenge) error
+	ConsumeChallenge(ctx context.Context, id uuid.UUID, purpose string) (*entities.WebAuthnChallenge, error)
+	DeleteExpiredChallenges(ctx context.Context) error
+}
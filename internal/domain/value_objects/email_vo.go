@@ -0,0 +1,11 @@
+package value_objects
+
+import "time"
+
+type EmailMessage struct {
+	Type      string
+	Email     string
+	Token     string
+	Code      string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,28 @@
+package value_objects
+
+type OAuthClientRegistration struct {
+	ClientID     string   `json:"client_id" validate:"required,max=128"`
+	Name         string   `json:"name" validate:"max=255"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,dive,url"`
+	GrantTypes   []string `json:"grant_types" validate:"dive,oneof=authorization_code refresh_token"`
+	Public       bool     `json:"public"`
+	FirstParty   bool     `json:"first_party"`
+}
+
+type OAuthClientCredentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+type AuthorizationRequest struct {
+	ClientID      string
+	RedirectURI   string
+	CodeChallenge string
+}
+
+type CodeExchange struct {
+	Client       OAuthClientCredentials
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+}
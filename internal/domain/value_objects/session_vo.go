@@ -0,0 +1,16 @@
+package value_objects
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionInfo struct {
+	ID              uuid.UUID
+	UserAgent       string
+	IPAddress       string
+	CreatedAt       time.Time
+	LastRefreshedAt time.Time
+	Current         bool
+}
@@ -0,0 +1,18 @@
+package value_objects
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenIntrospection struct {
+	Active    bool
+	TokenID   uuid.UUID
+	UserID    uuid.UUID
+	Type      string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Issuer    string
+	Audience  []string
+}
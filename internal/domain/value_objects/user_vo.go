@@ -1,11 +1,43 @@
 package value_objects
 
+import "github.com/google/uuid"
+
 type UserVO struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required,max=1024"`
+}
+
+type ChangePasswordVO struct {
+	OldPassword string `json:"old_password" validate:"required,max=1024"`
+	NewPassword string `json:"new_password" validate:"required,max=1024"`
 }
 
 type AuthResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	MFARequired  bool      `json:"mfa_required,omitempty"`
+	MFAChallenge string    `json:"mfa_challenge,omitempty"`
+	SessionID    uuid.UUID `json:"-"`
+}
+
+type MFAVerification struct {
+	Challenge    string
+	Code         string
+	RecoveryCode string
+}
+
+type PasskeyCeremony struct {
+	ChallengeID uuid.UUID
+	Options     []byte
+}
+
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type ClientInfo struct {
+	ClientID  string
+	UserAgent string
+	IPAddress string
 }
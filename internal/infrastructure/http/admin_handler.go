@@ -0,0 +1,126 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/value_objects"
+	"authService/internal/service"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+)
+
+type AdminHandler struct {
+	keys    *hashing.Keyring
+	service service.UserService
+	cfg     *config.Config
+	token   string
+}
+
+func NewAdminHandler(keys *hashing.Keyring, userService service.UserService, cfg *config.Config) *AdminHandler {
+	return &AdminHandler{
+		keys:    keys,
+		service: userService,
+		cfg:     cfg,
+		token:   cfg.AdminToken,
+	}
+}
+
+func (h *AdminHandler) Register(mux *http.ServeMux) {
+	if h.token == "" {
+		log.Println("ADMIN_TOKEN is not set, admin endpoints are disabled")
+		return
+	}
+	mux.HandleFunc("/admin/keys/rotate", h.RotateKeys)
+	mux.HandleFunc("/admin/oauth/clients", h.RegisterOAuthClient)
+}
+
+func (h *AdminHandler) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	key, err := h.keys.Rotate()
+	if err != nil {
+		if errors.Is(err, hashing.ErrKeyringNotPersistent) {
+			http.Error(w, "Key rotation requires JWT_KEYS_DIR", http.StatusConflict)
+			return
+		}
+		log.Printf("Error rotating signing key: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	h.keys.Prune()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(map[string]string{
+		"kid":          key.ID,
+		"alg":          key.Algorithm,
+		"activates_at": h.keys.ActivatesAt(key).Format(time.RFC3339),
+	}); err != nil {
+		log.Printf("Failed to write rotate response: %v", err)
+	}
+}
+
+func (h *AdminHandler) RegisterOAuthClient(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	var registration value_objects.OAuthClientRegistration
+	if err := json.NewDecoder(r.Body).Decode(&registration); err != nil {
+		http.Error(w, "Malformed JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := validate.Struct(&registration); err != nil {
+		http.Error(w, "Invalid client registration", http.StatusBadRequest)
+		return
+	}
+
+	credentials, err := h.service.RegisterOAuthClient(r.Context(), h.cfg, registration)
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.UnknownClientError):
+			http.Error(w, "client_id must be listed in JWT_AUDIENCES", http.StatusBadRequest)
+		case errors.Is(err, service_errors.InvalidOAuthClientError):
+			http.Error(w, "client_id is reserved for the login page", http.StatusBadRequest)
+		case errors.Is(err, service_errors.InvalidRedirectURIError):
+			http.Error(w, "Redirect URIs must be absolute and have no fragment", http.StatusBadRequest)
+		case errors.Is(err, service_errors.OAuthClientAlreadyExistsError):
+			http.Error(w, "Client already exists", http.StatusConflict)
+		default:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(credentials); err != nil {
+		log.Printf("Failed to write client registration response: %v", err)
+	}
+}
+
+func (h *AdminHandler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/password_policy"
+	"authService/internal/utils/service_errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const maxUserAgentLength = 512
+
+func accessTokenFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", service_errors.MissingTokenError
+	}
+
+	for _, value := range md.Get("authorization") {
+		scheme, token, found := strings.Cut(value, " ")
+		if found && strings.EqualFold(scheme, "Bearer") && token != "" {
+			return strings.TrimSpace(token), nil
+		}
+	}
+
+	return "", service_errors.MissingTokenError
+}
+
+func clientInfoFromContext(ctx context.Context, clientID string) value_objects.ClientInfo {
+	client := value_objects.ClientInfo{ClientID: clientID}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			client.UserAgent = values[0]
+			if len(client.UserAgent) > maxUserAgentLength {
+				client.UserAgent = client.UserAgent[:maxUserAgentLength]
+			}
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client.IPAddress = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client.IPAddress); err == nil {
+			client.IPAddress = host
+		}
+	}
+
+	return client
+}
+
+func weakPasswordError(err error, field string) error {
+	var violations password_policy.Violations
+	if !errors.As(err, &violations) {
+		return status.Error(codes.InvalidArgument, "Password does not satisfy policy")
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: violation.Message,
+			Reason:      violation.Rule,
+		})
+	}
+
+	st, detailsErr := status.New(codes.InvalidArgument, "Password does not satisfy policy").WithDetails(badRequest)
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, violations.Error())
+	}
+	return st.Err()
+}
+
+func retryAfterStatusError(ctx context.Context, err error, message string) error {
+	var retryErr *service_errors.RetryAfterError
+	if !errors.As(err, &retryErr) {
+		return status.Error(codes.ResourceExhausted, message)
+	}
+
+	seconds := int64(math.Ceil(retryErr.RetryAfter.Seconds()))
+	if headerErr := grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10))); headerErr != nil {
+		log.Printf("Error setting retry-after header: %v", headerErr)
+	}
+
+	st, detailsErr := status.New(codes.ResourceExhausted, message).WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryErr.RetryAfter),
+	})
+	if detailsErr != nil {
+		return status.Error(codes.ResourceExhausted, message)
+	}
+	return st.Err()
+}
+
+func authStatusError(err error) error {
+	switch {
+	case errors.Is(err, service_errors.MissingTokenError):
+		return status.Error(codes.Unauthenticated, "Access token is required")
+	case errors.Is(err, service_errors.TokenExpiredError):
+		return status.Error(codes.Unauthenticated, "Token expired")
+	case errors.Is(err, service_errors.InvalidTokenError):
+		return status.Error(codes.Unauthenticated, "Invalid token")
+	case errors.Is(err, service_errors.TokenRevokedError):
+		return status.Error(codes.Unauthenticated, "Token revoked")
+	case errors.Is(err, service_errors.UserNotFoundError):
+		return status.Error(codes.Unauthenticated, "User not found or not active")
+	default:
+		return status.Error(codes.Internal, "Internal server error")
+	}
+}
+
+func emailLoginStatusError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, service_errors.InvalidLoginCodeError):
+		return status.Error(codes.Unauthenticated, "Invalid or expired login code")
+	case errors.Is(err, service_errors.TokenExpiredError),
+		errors.Is(err, service_errors.InvalidTokenError),
+		errors.Is(err, service_errors.TokenRevokedError):
+		return status.Error(codes.Unauthenticated, "Invalid or expired login link")
+	case errors.Is(err, service_errors.InvalidCredentialsError):
+		return status.Error(codes.Unauthenticated, "Invalid credentials or not active user")
+	case errors.Is(err, service_errors.EmailNotConfirmedError):
+		return status.Error(codes.FailedPrecondition, "Email is not confirmed")
+	case errors.Is(err, service_errors.AccountLockedError):
+		return retryAfterStatusError(ctx, err, "Too many failed login attempts, try again later")
+	case errors.Is(err, service_errors.UnknownClientError):
+		return status.Error(codes.InvalidArgument, "Unknown client")
+	default:
+		return status.Error(codes.Internal, "Internal server error")
+	}
+}
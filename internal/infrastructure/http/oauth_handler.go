@@ -0,0 +1,506 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/value_objects"
+	"authService/internal/service"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+)
+
+const (
+	oauthInvalidRequest          = "invalid_request"
+	oauthInvalidClient           = "invalid_client"
+	oauthInvalidGrant            = "invalid_grant"
+	oauthUnauthorizedClient      = "unauthorized_client"
+	oauthUnsupportedGrantType    = "unsupported_grant_type"
+	oauthUnsupportedResponseType = "unsupported_response_type"
+	oauthServerError             = "server_error"
+	oauthTemporarilyUnavailable  = "temporarily_unavailable"
+	oauthAccessDenied            = "access_denied"
+	oauthLoginRequired           = "login_required"
+	oauthConsentRequired         = "consent_required"
+	oauthInvalidToken            = "invalid_token"
+)
+
+// The __Host- prefix makes browsers require Secure and Path=/ and reject a
+// Domain attribute, so sibling subdomains cannot plant the session.
+const oauthSessionCookie = "__Host-oauth_session"
+
+type OAuthHandler struct {
+	service service.UserService
+	cfg     *config.Config
+}
+
+func NewOAuthHandler(userService service.UserService, cfg *config.Config) *OAuthHandler {
+	return &OAuthHandler{
+		service: userService,
+		cfg:     cfg,
+	}
+}
+
+func (h *OAuthHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/authorize", h.Authorize)
+	mux.HandleFunc("/token", h.Token)
+	mux.HandleFunc("/oauth/session", h.Session)
+	mux.HandleFunc("/oauth/consent", h.Consent)
+}
+
+// Authorize implements the authorization endpoint. The end user is identified
+// by the session cookie set through /oauth/session; without one the browser is
+// sent to OAUTH_LOGIN_URL, and third-party clients without consent to
+// OAUTH_CONSENT_URL.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "Malformed request")
+		return
+	}
+
+	request := value_objects.AuthorizationRequest{
+		ClientID:      r.Form.Get("client_id"),
+		RedirectURI:   r.Form.Get("redirect_uri"),
+		CodeChallenge: r.Form.Get("code_challenge"),
+	}
+	state := r.Form.Get("state")
+
+	if err := h.service.ValidateAuthorizationRequest(r.Context(), request); err != nil {
+		writeAuthorizationRequestError(w, r, request, state, err)
+		return
+	}
+
+	if r.Form.Get("response_type") != "code" {
+		redirectOAuthError(w, r, request.RedirectURI, state, oauthUnsupportedResponseType)
+		return
+	}
+	if r.Form.Get("code_challenge_method") != hashing.PKCEMethodS256 || !hashing.ValidPKCEValue(request.CodeChallenge) {
+		redirectOAuthError(w, r, request.RedirectURI, state, oauthInvalidRequest)
+		return
+	}
+
+	cookie, err := r.Cookie(oauthSessionCookie)
+	if err != nil || cookie.Value == "" {
+		h.requireLogin(w, r, request.RedirectURI, state)
+		return
+	}
+
+	code, err := h.service.Authorize(r.Context(), h.cfg, cookie.Value, request)
+	if err != nil {
+		switch {
+		case isSessionTokenError(err):
+			h.requireLogin(w, r, request.RedirectURI, state)
+		case errors.Is(err, service_errors.ConsentRequiredError):
+			h.requireConsent(w, r, request.ClientID, request.RedirectURI, state)
+		case errors.Is(err, service_errors.OAuthLoginUnavailableError):
+			writeOAuthError(w, http.StatusServiceUnavailable, oauthTemporarilyUnavailable, "OAuth login is not configured")
+		default:
+			redirectOAuthError(w, r, request.RedirectURI, state, oauthServerError)
+		}
+		return
+	}
+
+	params := url.Values{"code": {code}}
+	if state != "" {
+		params.Set("state", state)
+	}
+	redirectWithParams(w, r, request.RedirectURI, params)
+}
+
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "Malformed request")
+		return
+	}
+
+	credentials, basicAuth, err := clientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, err.Error())
+		return
+	}
+
+	var tokens value_objects.AuthResponse
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		exchange := value_objects.CodeExchange{
+			Client:       credentials,
+			Code:         r.PostForm.Get("code"),
+			RedirectURI:  r.PostForm.Get("redirect_uri"),
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+		}
+		if exchange.Code == "" || exchange.RedirectURI == "" || exchange.CodeVerifier == "" {
+			writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "code, redirect_uri and code_verifier are required")
+			return
+		}
+		tokens, err = h.service.ExchangeAuthorizationCode(r.Context(), h.cfg, exchange, clientInfoFromRequest(r))
+	case "refresh_token":
+		refreshToken := r.PostForm.Get("refresh_token")
+		if refreshToken == "" {
+			writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "refresh_token is required")
+			return
+		}
+		tokens, err = h.service.RefreshOAuthToken(r.Context(), h.cfg, credentials, refreshToken)
+	case "":
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "grant_type is required")
+		return
+	default:
+		writeOAuthError(w, http.StatusBadRequest, oauthUnsupportedGrantType, "Unsupported grant type")
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.InvalidOAuthClientError):
+			if basicAuth {
+				w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+			}
+			writeOAuthError(w, http.StatusUnauthorized, oauthInvalidClient, "Client authentication failed")
+		case errors.Is(err, service_errors.UnauthorizedClientError):
+			writeOAuthError(w, http.StatusBadRequest, oauthUnauthorizedClient, "Client is not allowed to use this grant type")
+		case errors.Is(err, service_errors.InvalidGrantError):
+			writeOAuthError(w, http.StatusBadRequest, oauthInvalidGrant, "Invalid or expired grant")
+		default:
+			writeOAuthError(w, http.StatusInternalServerError, oauthServerError, "Internal server error")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  tokens.AccessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(h.cfg.JWT.AccessTTL().Seconds()),
+		"refresh_token": tokens.RefreshToken,
+	})
+}
+
+// Session stores the login page's access token in the /authorize session
+// cookie. The token is accepted only in the Authorization header: a cross-site
+// form cannot set it and a cross-origin fetch with it needs a CORS preflight
+// that is never granted, so another site cannot log the browser into its own
+// account. The validated return_to is echoed back for the page to navigate to.
+// DELETE clears the cookie.
+func (h *OAuthHandler) Session(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		http.SetCookie(w, sessionCookie("", -1))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "Malformed request")
+		return
+	}
+
+	returnTo, ok := authorizeReturnTo(r.PostForm.Get("return_to"))
+	if !ok {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "return_to must point to /authorize")
+		return
+	}
+	sessionToken, ok := bearerToken(r)
+	if !ok {
+		writeSessionTokenError(w, service_errors.MissingTokenError)
+		return
+	}
+
+	expiresAt, err := h.service.ValidateOAuthSession(r.Context(), h.cfg, sessionToken)
+	if err != nil {
+		writeSessionTokenError(w, err)
+		return
+	}
+
+	http.SetCookie(w, sessionCookie(sessionToken, int(time.Until(expiresAt).Seconds())))
+	if returnTo == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]string{"return_to": returnTo})
+}
+
+// Consent records the decision made on the consent page for the /authorize
+// request in return_to. The login page's token is required, in the header or
+// the access_token form field; the session cookie is deliberately not
+// accepted, so a cross-site form cannot grant consent on the user's behalf.
+func (h *OAuthHandler) Consent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "Malformed request")
+		return
+	}
+
+	returnTo, ok := authorizeReturnTo(r.PostForm.Get("return_to"))
+	if !ok || returnTo == "" {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "return_to must point to /authorize")
+		return
+	}
+	location, err := url.Parse(returnTo)
+	if err != nil {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "Malformed return_to")
+		return
+	}
+	query := location.Query()
+	request := value_objects.AuthorizationRequest{
+		ClientID:      query.Get("client_id"),
+		RedirectURI:   query.Get("redirect_uri"),
+		CodeChallenge: query.Get("code_challenge"),
+	}
+	state := query.Get("state")
+
+	decision := r.PostForm.Get("decision")
+	if decision != "allow" && decision != "deny" {
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "decision must be allow or deny")
+		return
+	}
+	sessionToken, ok := loginToken(r)
+	if !ok {
+		writeSessionTokenError(w, service_errors.MissingTokenError)
+		return
+	}
+
+	if decision == "allow" {
+		err = h.service.GrantOAuthConsent(r.Context(), h.cfg, sessionToken, request)
+	} else if _, err = h.service.ValidateOAuthSession(r.Context(), h.cfg, sessionToken); err == nil {
+		err = h.service.ValidateAuthorizationRequest(r.Context(), request)
+	}
+	if err != nil {
+		if isSessionTokenError(err) || errors.Is(err, service_errors.OAuthLoginUnavailableError) {
+			writeSessionTokenError(w, err)
+		} else {
+			writeAuthorizationRequestError(w, r, request, state, err)
+		}
+		return
+	}
+
+	if decision == "deny" {
+		redirectOAuthError(w, r, request.RedirectURI, state, oauthAccessDenied)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, returnTo, http.StatusSeeOther)
+}
+
+func (h *OAuthHandler) requireLogin(w http.ResponseWriter, r *http.Request, redirectURI string, state string) {
+	if r.Form.Get("prompt") == "none" {
+		redirectOAuthError(w, r, redirectURI, state, oauthLoginRequired)
+		return
+	}
+	if h.cfg.OAuth.LoginURL == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="authorize"`)
+		writeOAuthError(w, http.StatusUnauthorized, oauthLoginRequired, "User authentication is required")
+		return
+	}
+
+	redirectWithParams(w, r, h.cfg.OAuth.LoginURL, url.Values{"return_to": {authorizeURL(r)}})
+}
+
+func (h *OAuthHandler) requireConsent(w http.ResponseWriter, r *http.Request, clientID string, redirectURI string, state string) {
+	if r.Form.Get("prompt") == "none" || h.cfg.OAuth.ConsentURL == "" {
+		redirectOAuthError(w, r, redirectURI, state, oauthConsentRequired)
+		return
+	}
+
+	redirectWithParams(w, r, h.cfg.OAuth.ConsentURL, url.Values{
+		"return_to": {authorizeURL(r)},
+		"client_id": {clientID},
+	})
+}
+
+func writeAuthorizationRequestError(
+	w http.ResponseWriter,
+	r *http.Request,
+	request value_objects.AuthorizationRequest,
+	state string,
+	err error,
+) {
+	switch {
+	case errors.Is(err, service_errors.InvalidOAuthClientError):
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidClient, "Unknown client")
+	case errors.Is(err, service_errors.InvalidRedirectURIError):
+		writeOAuthError(w, http.StatusBadRequest, oauthInvalidRequest, "Redirect URI is not registered for this client")
+	case errors.Is(err, service_errors.UnauthorizedClientError):
+		redirectOAuthError(w, r, request.RedirectURI, state, oauthUnauthorizedClient)
+	default:
+		writeOAuthError(w, http.StatusInternalServerError, oauthServerError, "Internal server error")
+	}
+}
+
+func writeSessionTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case isSessionTokenError(err):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="oauth", error="invalid_token"`)
+		writeOAuthError(w, http.StatusUnauthorized, oauthInvalidToken, "A valid login token is required")
+	case errors.Is(err, service_errors.OAuthLoginUnavailableError):
+		writeOAuthError(w, http.StatusServiceUnavailable, oauthTemporarilyUnavailable, "OAuth login is not configured")
+	default:
+		writeOAuthError(w, http.StatusInternalServerError, oauthServerError, "Internal server error")
+	}
+}
+
+func isSessionTokenError(err error) bool {
+	return errors.Is(err, service_errors.MissingTokenError) ||
+		errors.Is(err, service_errors.InvalidTokenError) ||
+		errors.Is(err, service_errors.TokenExpiredError) ||
+		errors.Is(err, service_errors.TokenRevokedError) ||
+		errors.Is(err, service_errors.UserNotFoundError)
+}
+
+func sessionCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oauthSessionCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func loginToken(r *http.Request) (string, bool) {
+	if token, ok := bearerToken(r); ok {
+		return token, true
+	}
+	token := strings.TrimSpace(r.PostForm.Get("access_token"))
+	return token, token != ""
+}
+
+// authorizeURL is the current /authorize request as a relative URL, passed to
+// the login and consent pages as return_to.
+func authorizeURL(r *http.Request) string {
+	returnTo := url.URL{Path: r.URL.Path, RawQuery: r.Form.Encode()}
+	return returnTo.String()
+}
+
+// authorizeReturnTo accepts only relative links to /authorize, so the session
+// and consent endpoints cannot be used as open redirects.
+func authorizeReturnTo(raw string) (string, bool) {
+	if raw == "" {
+		return "", true
+	}
+	location, err := url.Parse(raw)
+	if err != nil || location.Scheme != "" || location.Host != "" || location.User != nil || location.Path != "/authorize" {
+		return "", false
+	}
+	returnTo := url.URL{Path: location.Path, RawQuery: location.RawQuery}
+	return returnTo.String(), true
+}
+
+// clientCredentials reads client_secret_basic or client_secret_post
+// credentials; public clients send only client_id in the form.
+func clientCredentials(r *http.Request) (value_objects.OAuthClientCredentials, bool, error) {
+	formID := r.PostForm.Get("client_id")
+	formSecret := r.PostForm.Get("client_secret")
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return value_objects.OAuthClientCredentials{ClientID: formID, ClientSecret: formSecret}, false, nil
+	}
+	if formSecret != "" {
+		return value_objects.OAuthClientCredentials{}, true, errors.New("multiple client authentication methods")
+	}
+
+	clientID, err := url.QueryUnescape(username)
+	if err != nil {
+		return value_objects.OAuthClientCredentials{}, true, errors.New("malformed client credentials")
+	}
+	clientSecret, err := url.QueryUnescape(password)
+	if err != nil {
+		return value_objects.OAuthClientCredentials{}, true, errors.New("malformed client credentials")
+	}
+	if formID != "" && formID != clientID {
+		return value_objects.OAuthClientCredentials{}, true, errors.New("client_id does not match credentials")
+	}
+
+	return value_objects.OAuthClientCredentials{ClientID: clientID, ClientSecret: clientSecret}, true, nil
+}
+
+func clientInfoFromRequest(r *http.Request) value_objects.ClientInfo {
+	client := value_objects.ClientInfo{UserAgent: r.UserAgent()}
+	if len(client.UserAgent) > maxUserAgentLength {
+		client.UserAgent = client.UserAgent[:maxUserAgentLength]
+	}
+
+	client.IPAddress = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		client.IPAddress = host
+	}
+
+	return client
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func redirectOAuthError(w http.ResponseWriter, r *http.Request, redirectURI string, state string, code string) {
+	params := url.Values{"error": {code}}
+	if state != "" {
+		params.Set("state", state)
+	}
+	redirectWithParams(w, r, redirectURI, params)
+}
+
+func redirectWithParams(w http.ResponseWriter, r *http.Request, target string, params url.Values) {
+	location, err := url.Parse(target)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, oauthServerError, "Internal server error")
+		return
+	}
+
+	query := location.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	location.RawQuery = query.Encode()
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, location.String(), http.StatusFound)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string, description string) {
+	writeJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Printf("Failed to write oauth response: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/service"
+	"authService/internal/utils/service_errors"
+)
+
+// fakeSessionService accepts a single login token; any other method panics.
+type fakeSessionService struct {
+	service.UserService
+	token string
+}
+
+func (s fakeSessionService) ValidateOAuthSession(ctx context.Context, cfg *config.Config, sessionToken string) (time.Time, error) {
+	if sessionToken != s.token {
+		return time.Time{}, service_errors.InvalidTokenError
+	}
+	return time.Now().Add(time.Hour), nil
+}
+
+func TestSessionAcceptsOnlyAuthorizationHeader(t *testing.T) {
+	const token = "login-token"
+	handler := NewOAuthHandler(fakeSessionService{token: token}, &config.Config{})
+	form := url.Values{"return_to": {"/authorize?client_id=web"}}
+
+	tests := []struct {
+		name          string
+		form          url.Values
+		authorization string
+		wantStatus    int
+	}{
+		{name: "bearer header", form: form, authorization: "Bearer " + token, wantStatus: http.StatusOK},
+		{name: "form field", form: url.Values{"return_to": form["return_to"], "access_token": {token}}, wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", form: form, authorization: "Bearer other-token", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/oauth/session", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.Session(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			cookies := w.Result().Cookies()
+			if tt.wantStatus != http.StatusOK {
+				if len(cookies) != 0 {
+					t.Errorf("session cookie set on a rejected request: %v", cookies)
+				}
+				return
+			}
+
+			if len(cookies) != 1 || cookies[0].Name != oauthSessionCookie || cookies[0].Value != token {
+				t.Errorf("cookies = %v, want %s with the login token", cookies, oauthSessionCookie)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body["return_to"] != "/authorize?client_id=web" {
+				t.Errorf("return_to = %q, want %q", body["return_to"], "/authorize?client_id=web")
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"authService/internal/service"
 	"authService/internal/utils/service_errors"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -39,11 +40,17 @@ func (s *GRPCServer) Register(ctx context.Context, req *api.AuthRequest) (*api.R
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	if err = s.service.Register(ctx, &userRegistry); err != nil {
-		if errors.Is(err, service_errors.InternalServerError) {
+	if err = s.service.Register(ctx, s.cfg, &userRegistry); err != nil {
+		switch {
+		case errors.Is(err, service_errors.WeakPasswordError):
+			return nil, weakPasswordError(err, "password")
+		case errors.Is(err, service_errors.PasswordTooLongError):
+			return nil, status.Error(codes.InvalidArgument, "Password is too long")
+		case errors.Is(err, service_errors.InternalServerError):
 			return nil, status.Error(codes.Internal, err.Error())
+		default:
+			return nil, status.Error(codes.AlreadyExists, err.Error())
 		}
-		return nil, status.Error(codes.AlreadyExists, err.Error())
 	}
 
 	return &api.RegisterResponse{
@@ -61,11 +68,17 @@ func (s *GRPCServer) Login(ctx context.Context, req *api.AuthRequest) (*api.Auth
 		return nil, status.Error(codes.InvalidArgument, "Invalid email or password format")
 	}
 
-	tokens, err := s.service.Login(ctx, s.cfg, &userLogin)
+	tokens, err := s.service.Login(ctx, s.cfg, &userLogin, clientInfoFromContext(ctx, req.ClientId))
 	if err != nil {
 		switch {
+		case errors.Is(err, service_errors.UnknownClientError):
+			return nil, status.Error(codes.InvalidArgument, "Unknown client")
 		case errors.Is(err, service_errors.InvalidCredentialsError):
 			return nil, status.Error(codes.Unauthenticated, "Invalid credentials or not active user")
+		case errors.Is(err, service_errors.EmailNotConfirmedError):
+			return nil, status.Error(codes.FailedPrecondition, "Email is not confirmed")
+		case errors.Is(err, service_errors.AccountLockedError):
+			return nil, retryAfterStatusError(ctx, err, "Too many failed login attempts, try again later")
 		case errors.Is(err, service_errors.InternalServerError):
 			return nil, status.Error(codes.Internal, "Internal server error")
 		default:
@@ -76,13 +89,554 @@ func (s *GRPCServer) Login(ctx context.Context, req *api.AuthRequest) (*api.Auth
 	return &api.AuthResponse{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
+		MfaRequired:  tokens.MFARequired,
+		MfaChallenge: tokens.MFAChallenge,
 	}, nil
 }
 
 func (s *GRPCServer) RefreshTokens(ctx context.Context, req *api.RefreshToken) (*api.AuthResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "Refresh token is required")
+	}
+
+	tokens, err := s.service.Refresh(ctx, s.cfg, req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.TokenExpiredError):
+			return nil, status.Error(codes.Unauthenticated, "Refresh token expired")
+		case errors.Is(err, service_errors.InvalidTokenError):
+			return nil, status.Error(codes.Unauthenticated, "Invalid refresh token")
+		case errors.Is(err, service_errors.RefreshTokenReuseError):
+			return nil, status.Error(codes.Unauthenticated, "Refresh token reuse detected, session revoked")
+		case errors.Is(err, service_errors.TokenRevokedError):
+			return nil, status.Error(codes.Unauthenticated, "Refresh token revoked")
+		case errors.Is(err, service_errors.UserNotFoundError):
+			return nil, status.Error(codes.Unauthenticated, "User not found or not active")
+		default:
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+	}
+
 	return &api.AuthResponse{
-		AccessToken:  "test_token",
-		RefreshToken: "tes_token",
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+// Introspect authenticates the calling service by one of INTROSPECTION_SECRETS
+// in the authorization metadata, in place of an access token.
+func (s *GRPCServer) Introspect(ctx context.Context, req *api.IntrospectRequest) (*api.IntrospectResponse, error) {
+	callerSecret, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Caller credentials are required")
+	}
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "Token is required")
+	}
+
+	introspection, err := s.service.Introspect(ctx, s.cfg, callerSecret, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.MissingTokenError),
+			errors.Is(err, service_errors.InvalidCallerCredentialsError):
+			return nil, status.Error(codes.Unauthenticated, "Invalid caller credentials")
+		case errors.Is(err, service_errors.IntrospectionUnavailableError):
+			return nil, status.Error(codes.FailedPrecondition, "Introspection is not configured")
+		default:
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+	}
+
+	if !introspection.Active {
+		return &api.IntrospectResponse{Active: false}, nil
+	}
+
+	response := &api.IntrospectResponse{
+		Active: true,
+		Sub:    introspection.UserID.String(),
+		Exp:    introspection.ExpiresAt.Unix(),
+		Type:   introspection.Type,
+		Iss:    introspection.Issuer,
+		Aud:    introspection.Audience,
+	}
+	if !introspection.IssuedAt.IsZero() {
+		response.Iat = introspection.IssuedAt.Unix()
+	}
+	if introspection.TokenID != uuid.Nil {
+		response.Jti = introspection.TokenID.String()
+	}
+
+	return response, nil
+}
+
+func (s *GRPCServer) Logout(ctx context.Context, req *api.LogoutRequest) (*api.LogoutResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	if err = s.service.Logout(ctx, s.cfg, accessToken, req.RefreshToken); err != nil {
+		return nil, authStatusError(err)
+	}
+
+	return &api.LogoutResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) LogoutAll(ctx context.Context, req *api.LogoutAllRequest) (*api.LogoutResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	if err = s.service.LogoutAll(ctx, s.cfg, accessToken); err != nil {
+		return nil, authStatusError(err)
+	}
+
+	return &api.LogoutResponse{
+		Success: true,
 	}, nil
+}
 
+func (s *GRPCServer) ListSessions(ctx context.Context, req *api.ListSessionsRequest) (*api.ListSessionsResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	sessions, err := s.service.ListSessions(ctx, s.cfg, accessToken)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	response := &api.ListSessionsResponse{
+		Sessions: make([]*api.Session, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, &api.Session{
+			Id:              session.ID.String(),
+			UserAgent:       session.UserAgent,
+			IpAddress:       session.IPAddress,
+			CreatedAt:       session.CreatedAt.Unix(),
+			LastRefreshedAt: session.LastRefreshedAt.Unix(),
+			Current:         session.Current,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *GRPCServer) RevokeSession(ctx context.Context, req *api.RevokeSessionRequest) (*api.RevokeSessionResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	sessionID, err := uuid.Parse(req.SessionId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid session id")
+	}
+
+	if err = s.service.RevokeSession(ctx, s.cfg, accessToken, sessionID); err != nil {
+		if errors.Is(err, service_errors.SessionNotFoundError) {
+			return nil, status.Error(codes.NotFound, "Session not found")
+		}
+		return nil, authStatusError(err)
+	}
+
+	return &api.RevokeSessionResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) ConfirmEmail(ctx context.Context, req *api.ConfirmEmailRequest) (*api.ConfirmEmailResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "Token is required")
+	}
+
+	if err := s.service.ConfirmEmail(ctx, req.Token); err != nil {
+		if errors.Is(err, service_errors.InvalidTokenError) {
+			return nil, status.Error(codes.InvalidArgument, "Invalid or expired confirmation token")
+		}
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return &api.ConfirmEmailResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) ResendConfirmation(ctx context.Context, req *api.ResendConfirmationRequest) (*api.ResendConfirmationResponse, error) {
+	if err := validate.Var(req.Email, "required,email"); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid email format")
+	}
+
+	if err := s.service.ResendConfirmation(ctx, s.cfg, req.Email); err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return &api.ResendConfirmationResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) RequestPasswordReset(ctx context.Context, req *api.RequestPasswordResetRequest) (*api.RequestPasswordResetResponse, error) {
+	if err := validate.Var(req.Email, "required,email"); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid email format")
+	}
+
+	if err := s.service.RequestPasswordReset(ctx, s.cfg, req.Email); err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return &api.RequestPasswordResetResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) ResetPassword(ctx context.Context, req *api.ResetPasswordRequest) (*api.ResetPasswordResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "Token is required")
+	}
+	if err := validate.Var(req.NewPassword, "required,max=1024"); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid new password")
+	}
+
+	if err := s.service.ResetPassword(ctx, s.cfg, req.Token, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, service_errors.InvalidTokenError):
+			return nil, status.Error(codes.InvalidArgument, "Invalid or expired reset token")
+		case errors.Is(err, service_errors.WeakPasswordError):
+			return nil, weakPasswordError(err, "new_password")
+		case errors.Is(err, service_errors.PasswordTooLongError):
+			return nil, status.Error(codes.InvalidArgument, "New password is too long")
+		default:
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+	}
+
+	return &api.ResetPasswordResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) ChangePassword(ctx context.Context, req *api.ChangePasswordRequest) (*api.ChangePasswordResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	change := value_objects.ChangePasswordVO{
+		OldPassword: req.OldPassword,
+		NewPassword: req.NewPassword,
+	}
+	if err = validate.Struct(&change); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid password format")
+	}
+
+	if err = s.service.ChangePassword(ctx, s.cfg, accessToken, &change, req.LogoutOtherSessions); err != nil {
+		switch {
+		case errors.Is(err, service_errors.InvalidCredentialsError):
+			return nil, status.Error(codes.PermissionDenied, "Invalid old password")
+		case errors.Is(err, service_errors.AccountLockedError):
+			return nil, retryAfterStatusError(ctx, err, "Too many failed login attempts, try again later")
+		case errors.Is(err, service_errors.WeakPasswordError):
+			return nil, weakPasswordError(err, "new_password")
+		case errors.Is(err, service_errors.PasswordTooLongError):
+			return nil, status.Error(codes.InvalidArgument, "New password is too long")
+		default:
+			return nil, authStatusError(err)
+		}
+	}
+
+	return &api.ChangePasswordResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) EnrollTOTP(ctx context.Context, req *api.EnrollTOTPRequest) (*api.EnrollTOTPResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	enrollment, err := s.service.EnrollTOTP(ctx, s.cfg, accessToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.MFAAlreadyEnabledError):
+			return nil, status.Error(codes.AlreadyExists, "Two-factor authentication is already enabled")
+		case errors.Is(err, service_errors.MFAUnavailableError):
+			return nil, status.Error(codes.FailedPrecondition, "Two-factor authentication is not available")
+		default:
+			return nil, authStatusError(err)
+		}
+	}
+
+	return &api.EnrollTOTPResponse{
+		Secret:     enrollment.Secret,
+		OtpauthUri: enrollment.URI,
+	}, nil
+}
+
+func (s *GRPCServer) ConfirmTOTP(ctx context.Context, req *api.ConfirmTOTPRequest) (*api.ConfirmTOTPResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	if err = validate.Var(req.Code, "required,numeric"); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid code format")
+	}
+
+	recoveryCodes, err := s.service.ConfirmTOTP(ctx, s.cfg, accessToken, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.InvalidMFACodeError):
+			return nil, status.Error(codes.InvalidArgument, "Invalid verification code")
+		case errors.Is(err, service_errors.MFANotEnrolledError):
+			return nil, status.Error(codes.FailedPrecondition, "Two-factor enrollment not started")
+		case errors.Is(err, service_errors.MFAAlreadyEnabledError):
+			return nil, status.Error(codes.AlreadyExists, "Two-factor authentication is already enabled")
+		default:
+			return nil, authStatusError(err)
+		}
+	}
+
+	return &api.ConfirmTOTPResponse{
+		Success:       true,
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (s *GRPCServer) VerifyMFA(ctx context.Context, req *api.VerifyMFARequest) (*api.AuthResponse, error) {
+	if req.Challenge == "" {
+		return nil, status.Error(codes.InvalidArgument, "Challenge is required")
+	}
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		return nil, status.Error(codes.InvalidArgument, "Either code or recovery code is required")
+	}
+	if req.Code != "" {
+		if err := validate.Var(req.Code, "numeric"); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "Invalid code format")
+		}
+	}
+
+	verification := value_objects.MFAVerification{
+		Challenge:    req.Challenge,
+		Code:         req.Code,
+		RecoveryCode: req.RecoveryCode,
+	}
+	tokens, err := s.service.VerifyMFA(ctx, s.cfg, verification, clientInfoFromContext(ctx, ""))
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.InvalidMFACodeError):
+			return nil, status.Error(codes.Unauthenticated, "Invalid verification code")
+		case errors.Is(err, service_errors.TokenExpiredError),
+			errors.Is(err, service_errors.InvalidTokenError),
+			errors.Is(err, service_errors.TokenRevokedError):
+			return nil, status.Error(codes.Unauthenticated, "Invalid or expired MFA challenge")
+		case errors.Is(err, service_errors.AccountLockedError):
+			return nil, retryAfterStatusError(ctx, err, "Too many failed login attempts, try again later")
+		case errors.Is(err, service_errors.UnknownClientError):
+			return nil, status.Error(codes.InvalidArgument, "Unknown client")
+		default:
+			return nil, authStatusError(err)
+		}
+	}
+
+	return &api.AuthResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *GRPCServer) RegenerateRecoveryCodes(ctx context.Context, req *api.RegenerateRecoveryCodesRequest) (*api.RecoveryCodesResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	if err = validate.Var(req.Password, "required,max=1024"); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid password")
+	}
+
+	recoveryCodes, err := s.service.RegenerateRecoveryCodes(ctx, s.cfg, accessToken, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.InvalidCredentialsError):
+			return nil, status.Error(codes.PermissionDenied, "Invalid password")
+		case errors.Is(err, service_errors.AccountLockedError):
+			return nil, retryAfterStatusError(ctx, err, "Too many failed login attempts, try again later")
+		case errors.Is(err, service_errors.MFANotEnrolledError):
+			return nil, status.Error(codes.FailedPrecondition, "Two-factor authentication is not enabled")
+		default:
+			return nil, authStatusError(err)
+		}
+	}
+
+	return &api.RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (s *GRPCServer) BeginPasskeyRegistration(ctx context.Context, req *api.BeginPasskeyRegistrationRequest) (*api.PasskeyOptions, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	ceremony, err := s.service.BeginPasskeyRegistration(ctx, s.cfg, accessToken)
+	if err != nil {
+		if errors.Is(err, service_errors.PasskeysUnavailableError) {
+			return nil, status.Error(codes.FailedPrecondition, "Passkeys are not available")
+		}
+		return nil, authStatusError(err)
+	}
+
+	return &api.PasskeyOptions{
+		ChallengeId: ceremony.ChallengeID.String(),
+		OptionsJson: string(ceremony.Options),
+	}, nil
+}
+
+func (s *GRPCServer) FinishPasskeyRegistration(ctx context.Context, req *api.FinishPasskeyRegistrationRequest) (*api.FinishPasskeyRegistrationResponse, error) {
+	accessToken, err := accessTokenFromContext(ctx)
+	if err != nil {
+		return nil, authStatusError(err)
+	}
+
+	challengeID, err := uuid.Parse(req.ChallengeId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid challenge id")
+	}
+	if req.CredentialJson == "" {
+		return nil, status.Error(codes.InvalidArgument, "Credential is required")
+	}
+
+	if err = s.service.FinishPasskeyRegistration(ctx, s.cfg, accessToken, challengeID, []byte(req.CredentialJson)); err != nil {
+		switch {
+		case errors.Is(err, service_errors.PasskeysUnavailableError):
+			return nil, status.Error(codes.FailedPrecondition, "Passkeys are not available")
+		case errors.Is(err, service_errors.PasskeyAlreadyRegisteredError):
+			return nil, status.Error(codes.AlreadyExists, "Passkey is already registered")
+		case errors.Is(err, service_errors.InvalidPasskeyError):
+			return nil, status.Error(codes.InvalidArgument, "Passkey verification failed")
+		case errors.Is(err, service_errors.InvalidTokenError):
+			return nil, status.Error(codes.InvalidArgument, "Invalid or expired challenge")
+		default:
+			return nil, authStatusError(err)
+		}
+	}
+
+	return &api.FinishPasskeyRegistrationResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) BeginPasskeyLogin(ctx context.Context, req *api.BeginPasskeyLoginRequest) (*api.PasskeyOptions, error) {
+	ceremony, err := s.service.BeginPasskeyLogin(ctx, s.cfg)
+	if err != nil {
+		if errors.Is(err, service_errors.PasskeysUnavailableError) {
+			return nil, status.Error(codes.FailedPrecondition, "Passkeys are not available")
+		}
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return &api.PasskeyOptions{
+		ChallengeId: ceremony.ChallengeID.String(),
+		OptionsJson: string(ceremony.Options),
+	}, nil
+}
+
+func (s *GRPCServer) FinishPasskeyLogin(ctx context.Context, req *api.FinishPasskeyLoginRequest) (*api.AuthResponse, error) {
+	challengeID, err := uuid.Parse(req.ChallengeId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid challenge id")
+	}
+	if req.CredentialJson == "" {
+		return nil, status.Error(codes.InvalidArgument, "Credential is required")
+	}
+
+	tokens, err := s.service.FinishPasskeyLogin(ctx, s.cfg, challengeID, []byte(req.CredentialJson), clientInfoFromContext(ctx, req.ClientId))
+	if err != nil {
+		switch {
+		case errors.Is(err, service_errors.PasskeysUnavailableError):
+			return nil, status.Error(codes.FailedPrecondition, "Passkeys are not available")
+		case errors.Is(err, service_errors.InvalidPasskeyError),
+			errors.Is(err, service_errors.InvalidCredentialsError):
+			return nil, status.Error(codes.Unauthenticated, "Passkey verification failed")
+		case errors.Is(err, service_errors.InvalidTokenError):
+			return nil, status.Error(codes.InvalidArgument, "Invalid or expired challenge")
+		case errors.Is(err, service_errors.EmailNotConfirmedError):
+			return nil, status.Error(codes.FailedPrecondition, "Email is not confirmed")
+		case errors.Is(err, service_errors.AccountLockedError):
+			return nil, retryAfterStatusError(ctx, err, "Too many failed login attempts, try again later")
+		case errors.Is(err, service_errors.UnknownClientError):
+			return nil, status.Error(codes.InvalidArgument, "Unknown client")
+		default:
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+	}
+
+	return &api.AuthResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *GRPCServer) RequestLoginCode(ctx context.Context, req *api.RequestLoginCodeRequest) (*api.RequestLoginCodeResponse, error) {
+	if err := validate.Var(req.Email, "required,email"); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid email format")
+	}
+
+	if err := s.service.RequestLoginCode(ctx, s.cfg, req.Email, req.ClientId); err != nil {
+		if errors.Is(err, service_errors.UnknownClientError) {
+			return nil, status.Error(codes.InvalidArgument, "Unknown client")
+		}
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return &api.RequestLoginCodeResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *GRPCServer) LoginWithCode(ctx context.Context, req *api.LoginWithCodeRequest) (*api.AuthResponse, error) {
+	if err := validate.Var(req.Email, "required,email"); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid email format")
+	}
+	if err := validate.Var(req.Code, "required,numeric,len=6"); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid code format")
+	}
+
+	tokens, err := s.service.LoginWithCode(ctx, s.cfg, req.Email, req.Code, clientInfoFromContext(ctx, req.ClientId))
+	if err != nil {
+		return nil, emailLoginStatusError(ctx, err)
+	}
+
+	return &api.AuthResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		MfaRequired:  tokens.MFARequired,
+		MfaChallenge: tokens.MFAChallenge,
+	}, nil
+}
+
+func (s *GRPCServer) LoginWithMagicLink(ctx context.Context, req *api.LoginWithMagicLinkRequest) (*api.AuthResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, "Token is required")
+	}
+
+	tokens, err := s.service.LoginWithMagicLink(ctx, s.cfg, req.Token, clientInfoFromContext(ctx, ""))
+	if err != nil {
+		return nil, emailLoginStatusError(ctx, err)
+	}
+
+	return &api.AuthResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		MfaRequired:  tokens.MFARequired,
+		MfaChallenge: tokens.MFAChallenge,
+	}, nil
 }
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"authService/internal/config"
+	"authService/internal/utils/hashing"
+)
+
+const discoveryCacheControl = "public, max-age=3600"
+
+var jwksCacheControl = fmt.Sprintf("public, max-age=%d, must-revalidate", int(hashing.JWKSMaxAge.Seconds()))
+
+type WellKnownHandler struct {
+	keys *hashing.Keyring
+	cfg  *config.Config
+}
+
+func NewWellKnownHandler(keys *hashing.Keyring, cfg *config.Config) *WellKnownHandler {
+	return &WellKnownHandler{
+		keys: keys,
+		cfg:  cfg,
+	}
+}
+
+func (h *WellKnownHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/.well-known/jwks.json", h.JWKS)
+	mux.HandleFunc("/.well-known/openid-configuration", h.OpenIDConfiguration)
+}
+
+func (h *WellKnownHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	if !allowReadOnly(w, r) {
+		return
+	}
+
+	writeCachedJSON(w, r, jwksCacheControl, h.keys.JWKS())
+}
+
+func (h *WellKnownHandler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	if !allowReadOnly(w, r) {
+		return
+	}
+
+	issuer := h.issuer(r)
+	writeCachedJSON(w, r, discoveryCacheControl, map[string]interface{}{
+		"issuer":                                issuer,
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported":      []string{hashing.PKCEMethodS256},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"subject_types_supported":               []string{"public"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "jti", "iat", "nbf", "exp", "type"},
+	})
+}
+
+func (h *WellKnownHandler) issuer(r *http.Request) string {
+	if h.cfg.JWT.Issuer != "" {
+		return strings.TrimSuffix(h.cfg.JWT.Issuer, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func allowReadOnly(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+
+	w.Header().Set("Allow", "GET, HEAD")
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	return false
+}
+
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, cacheControl string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to encode well-known response: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err = w.Write(body); err != nil {
+		log.Printf("Failed to write well-known response: %v", err)
+	}
+}
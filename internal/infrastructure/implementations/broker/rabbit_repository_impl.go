@@ -2,9 +2,11 @@ package broker
 
 import (
 	"log"
+	"time"
 
 	"authService/internal/config"
 	"authService/internal/domain/repositories"
+	"authService/internal/domain/value_objects"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -12,12 +14,13 @@ type RabbitRepositoryImpl struct {
 	cfg *config.Config
 }
 
-func (r *RabbitRepositoryImpl) NewConnection() *amqp.Connection {
+func (r *RabbitRepositoryImpl) NewConnection() (*amqp.Connection, error) {
 	conn, err := amqp.Dial(r.cfg.RabbitMQ.RabbitMQUrl())
 	if err != nil {
 		log.Printf("Failed to connect to RabbitMQ: %v", err)
+		return nil, err
 	}
-	return conn
+	return conn, nil
 }
 
 func NewRabbitRepositoryImpl(cfg *config.Config) repositories.RabbitRepository {
@@ -26,8 +29,14 @@ func NewRabbitRepositoryImpl(cfg *config.Config) repositories.RabbitRepository {
 	}
 }
 
-func (r *RabbitRepositoryImpl) CreateEmailMSG(email string) error {
-	conn := r.NewConnection()
+// CreateEmailMSG keeps the plain-text body with the recipient address that the
+// email consumer has always read; the message type, token, code and expiry
+// travel in the message headers.
+func (r *RabbitRepositoryImpl) CreateEmailMSG(queue string, message value_objects.EmailMessage) error {
+	conn, err := r.NewConnection()
+	if err != nil {
+		return err
+	}
 	defer func() {
 		if err := conn.Close(); err != nil {
 			log.Printf("Error closing connection: %v", err)
@@ -44,17 +53,33 @@ func (r *RabbitRepositoryImpl) CreateEmailMSG(email string) error {
 		}
 	}()
 
-	que, err := channel.QueueDeclare(r.cfg.BrokerConstants.EmailConfirm, true, false, false, false, nil)
+	que, err := channel.QueueDeclare(queue, true, false, false, false, nil)
 	if err != nil {
 		return err
 	}
 
 	err = channel.Publish("", que.Name, false, false, amqp.Publishing{
 		ContentType: "text/plain",
-		Body:        []byte(email),
+		Type:        message.Type,
+		Headers:     emailHeaders(message),
+		Body:        []byte(message.Email),
 	})
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func emailHeaders(message value_objects.EmailMessage) amqp.Table {
+	headers := amqp.Table{"type": message.Type}
+	if message.Token != "" {
+		headers["token"] = message.Token
+	}
+	if message.Code != "" {
+		headers["code"] = message.Code
+	}
+	if !message.ExpiresAt.IsZero() {
+		headers["expires_at"] = message.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return headers
+}
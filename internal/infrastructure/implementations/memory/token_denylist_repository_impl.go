@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"authService/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+type TokenDenylistRepositoryImpl struct {
+	mu     sync.RWMutex
+	tokens map[uuid.UUID]time.Time
+}
+
+func NewTokenDenylistRepositoryImpl() repositories.TokenDenylistRepository {
+	return &TokenDenylistRepositoryImpl{
+		tokens: make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (r *TokenDenylistRepositoryImpl) DenyToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[jti] = expiresAt
+	return nil
+}
+
+func (r *TokenDenylistRepositoryImpl) IsTokenDenied(ctx context.Context, jti uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expiresAt, ok := r.tokens[jti]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (r *TokenDenylistRepositoryImpl) DeleteExpiredTokens(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for jti, expiresAt := range r.tokens {
+		if !now.Before(expiresAt) {
+			delete(r.tokens, jti)
+		}
+	}
+	return nil
+}
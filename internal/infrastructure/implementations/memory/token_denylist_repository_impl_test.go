@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTokenDenylistRejectsDeniedToken(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTokenDenylistRepositoryImpl()
+
+	revoked := uuid.New()
+	if err := repo.DenyToken(ctx, revoked, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("DenyToken: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		jti  uuid.UUID
+		want bool
+	}{
+		{name: "revoked", jti: revoked, want: true},
+		{name: "unknown", jti: uuid.New(), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denied, err := repo.IsTokenDenied(ctx, tt.jti)
+			if err != nil {
+				t.Fatalf("IsTokenDenied: %v", err)
+			}
+			if denied != tt.want {
+				t.Errorf("IsTokenDenied = %v, want %v", denied, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenDenylistExpiry(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTokenDenylistRepositoryImpl()
+
+	expired := uuid.New()
+	live := uuid.New()
+	if err := repo.DenyToken(ctx, expired, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("DenyToken: %v", err)
+	}
+	if err := repo.DenyToken(ctx, live, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("DenyToken: %v", err)
+	}
+
+	if denied, _ := repo.IsTokenDenied(ctx, expired); denied {
+		t.Error("expired entry is still denied")
+	}
+
+	if err := repo.DeleteExpiredTokens(ctx); err != nil {
+		t.Fatalf("DeleteExpiredTokens: %v", err)
+	}
+
+	tokens := repo.(*TokenDenylistRepositoryImpl).tokens
+	if _, ok := tokens[expired]; ok {
+		t.Error("expired entry was not deleted")
+	}
+	if _, ok := tokens[live]; !ok {
+		t.Error("live entry was deleted")
+	}
+	if denied, _ := repo.IsTokenDenied(ctx, live); !denied {
+		t.Error("live entry is no longer denied")
+	}
+}
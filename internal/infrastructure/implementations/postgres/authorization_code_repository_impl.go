@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+var authorizationCodeColumns = []string{
+	"code_hash", "client_id", "user_id", "redirect_uri", "code_challenge",
+	"session_id", "expires_at", "used_at", "created_at",
+}
+
+type AuthorizationCodeRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAuthorizationCodeRepositoryImpl(db *sql.DB) repositories.AuthorizationCodeRepository {
+	return &AuthorizationCodeRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *AuthorizationCodeRepositoryImpl) InsertAuthorizationCode(ctx context.Context, code *entities.AuthorizationCode) error {
+	query, args, err := Psql.
+		Insert("oauth_authorization_codes").
+		Columns("code_hash", "client_id", "user_id", "redirect_uri", "code_challenge", "expires_at").
+		Values(code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.CodeChallenge, code.ExpiresAt).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert authorization code query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to insert authorization code: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *AuthorizationCodeRepositoryImpl) GetAuthorizationCode(ctx context.Context, codeHash []byte) (*entities.AuthorizationCode, error) {
+	query, args, err := Psql.
+		Select(authorizationCodeColumns...).
+		From("oauth_authorization_codes").
+		Where(squirrel.Eq{"code_hash": codeHash}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAuthorizationCode(r.db.QueryRowContext(ctx, query, args...))
+}
+
+func (r *AuthorizationCodeRepositoryImpl) ConsumeAuthorizationCode(ctx context.Context, codeHash []byte) (*entities.AuthorizationCode, error) {
+	query, args, err := Psql.
+		Update("oauth_authorization_codes").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"code_hash": codeHash,
+			"used_at":   nil,
+		}).
+		Where(squirrel.Expr("expires_at > NOW()")).
+		Suffix("RETURNING code_hash, client_id, user_id, redirect_uri, code_challenge, session_id, expires_at, used_at, created_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAuthorizationCode(r.db.QueryRowContext(ctx, query, args...))
+}
+
+func (r *AuthorizationCodeRepositoryImpl) SetAuthorizationCodeSession(ctx context.Context, codeHash []byte, sessionID uuid.UUID) error {
+	query, args, err := Psql.
+		Update("oauth_authorization_codes").
+		Set("session_id", sessionID).
+		Where(squirrel.Eq{"code_hash": codeHash}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to store authorization code session: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *AuthorizationCodeRepositoryImpl) DeleteExpiredAuthorizationCodes(ctx context.Context) error {
+	query, args, err := Psql.
+		Delete("oauth_authorization_codes").
+		Where(squirrel.Expr("expires_at < NOW()")).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func scanAuthorizationCode(row rowScanner) (*entities.AuthorizationCode, error) {
+	var code entities.AuthorizationCode
+	var sessionID uuid.NullUUID
+	var usedAt sql.NullTime
+
+	err := row.Scan(
+		&code.CodeHash,
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		&code.CodeChallenge,
+		&sessionID,
+		&code.ExpiresAt,
+		&usedAt,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessionID.Valid {
+		code.SessionID = &sessionID.UUID
+	}
+	if usedAt.Valid {
+		code.UsedAt = &usedAt.Time
+	}
+
+	return &code, nil
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+)
+
+type LoginAttemptRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptRepositoryImpl(db *sql.DB) repositories.LoginAttemptRepository {
+	return &LoginAttemptRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *LoginAttemptRepositoryImpl) GetLoginAttempt(ctx context.Context, key string) (*entities.LoginAttempt, error) {
+	query, args, err := Psql.
+		Select("key", "failures", "last_failure_at", "locked_until").
+		From("login_attempts").
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var attempt entities.LoginAttempt
+	var lockedUntil sql.NullTime
+
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&attempt.Key,
+		&attempt.Failures,
+		&attempt.LastFailureAt,
+		&lockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		attempt.LockedUntil = &lockedUntil.Time
+	}
+
+	return &attempt, nil
+}
+
+func (r *LoginAttemptRepositoryImpl) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	query, args, err := Psql.
+		Insert("login_attempts").
+		Columns("key", "failures", "last_failure_at").
+		Values(key, 1, squirrel.Expr("NOW()")).
+		Suffix(
+			"ON CONFLICT (key) DO UPDATE SET "+
+				"failures = CASE WHEN login_attempts.last_failure_at < NOW() - make_interval(secs => ?) "+
+				"THEN 1 ELSE login_attempts.failures + 1 END, "+
+				"last_failure_at = NOW() "+
+				"RETURNING failures",
+			window.Seconds(),
+		).
+		ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var failures int
+	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&failures); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (r *LoginAttemptRepositoryImpl) LockUntil(ctx context.Context, key string, until time.Time) error {
+	query, args, err := Psql.
+		Update("login_attempts").
+		Set("locked_until", until).
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *LoginAttemptRepositoryImpl) ResetLoginAttempts(ctx context.Context, key string) error {
+	query, args, err := Psql.
+		Delete("login_attempts").
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *LoginAttemptRepositoryImpl) DeleteStaleAttempts(ctx context.Context, olderThan time.Duration) error {
+	query, args, err := Psql.
+		Delete("login_attempts").
+		Where(squirrel.Expr("last_failure_at < NOW() - make_interval(secs => ?)", olderThan.Seconds())).
+		Where(squirrel.Or{
+			squirrel.Eq{"locked_until": nil},
+			squirrel.Expr("locked_until < NOW()"),
+		}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"authService/internal/utils/service_errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type OAuthClientRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewOAuthClientRepositoryImpl(db *sql.DB) repositories.OAuthClientRepository {
+	return &OAuthClientRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *OAuthClientRepositoryImpl) InsertClient(ctx context.Context, client *entities.OAuthClient) error {
+	query, args, err := Psql.
+		Insert("oauth_clients").
+		Columns("id", "name", "secret_hash", "redirect_uris", "grant_types", "is_public", "first_party").
+		Values(
+			client.ID,
+			client.Name,
+			client.SecretHash,
+			pq.Array(client.RedirectURIs),
+			pq.Array(client.GrantTypes),
+			client.Public,
+			client.FirstParty,
+		).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert oauth client query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return service_errors.OAuthClientAlreadyExistsError
+		}
+		log.Printf("Failed to insert oauth client: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *OAuthClientRepositoryImpl) GetClient(ctx context.Context, id string) (*entities.OAuthClient, error) {
+	query, args, err := Psql.
+		Select("id", "name", "secret_hash", "redirect_uris", "grant_types", "is_public", "first_party", "created_at").
+		From("oauth_clients").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var client entities.OAuthClient
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&client.ID,
+		&client.Name,
+		&client.SecretHash,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.GrantTypes),
+		&client.Public,
+		&client.FirstParty,
+		&client.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+func (r *OAuthClientRepositoryImpl) HasConsent(ctx context.Context, userID uuid.UUID, clientID string) (bool, error) {
+	query, args, err := Psql.
+		Select("1").
+		From("oauth_consents").
+		Where(squirrel.Eq{"user_id": userID, "client_id": clientID}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var granted int
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&granted)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *OAuthClientRepositoryImpl) InsertConsent(ctx context.Context, userID uuid.UUID, clientID string) error {
+	query, args, err := Psql.
+		Insert("oauth_consents").
+		Columns("user_id", "client_id").
+		Values(userID, clientID).
+		Suffix("ON CONFLICT (user_id, client_id) DO NOTHING").
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert oauth consent query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to insert oauth consent: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"authService/internal/utils/service_errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var passkeyColumns = []string{
+	"id", "user_id", "public_key", "attestation_type", "transports", "aaguid",
+	"sign_count", "backup_eligible", "backup_state", "clone_warning", "created_at", "last_used_at",
+}
+
+type PasskeyRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPasskeyRepositoryImpl(db *sql.DB) repositories.PasskeyRepository {
+	return &PasskeyRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *PasskeyRepositoryImpl) InsertPasskey(ctx context.Context, credential *entities.PasskeyCredential) error {
+	query, args, err := Psql.
+		Insert("webauthn_credentials").
+		Columns(
+			"id", "user_id", "public_key", "attestation_type", "transports", "aaguid",
+			"sign_count", "backup_eligible", "backup_state",
+		).
+		Values(
+			credential.ID,
+			credential.UserID,
+			credential.PublicKey,
+			credential.AttestationType,
+			pq.Array(credential.Transports),
+			credential.AAGUID,
+			int64(credential.SignCount),
+			credential.BackupEligible,
+			credential.BackupState,
+		).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert passkey query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return service_errors.PasskeyAlreadyRegisteredError
+		}
+		log.Printf("Failed to insert passkey: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PasskeyRepositoryImpl) GetPasskey(ctx context.Context, id []byte) (*entities.PasskeyCredential, error) {
+	query, args, err := Psql.
+		Select(passkeyColumns...).
+		From("webauthn_credentials").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPasskey(r.db.QueryRowContext(ctx, query, args...))
+}
+
+func (r *PasskeyRepositoryImpl) ListUserPasskeys(ctx context.Context, userID uuid.UUID) ([]entities.PasskeyCredential, error) {
+	query, args, err := Psql.
+		Select(passkeyColumns...).
+		From("webauthn_credentials").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("created_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []entities.PasskeyCredential
+	for rows.Next() {
+		credential, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, *credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (r *PasskeyRepositoryImpl) UpdatePasskeyUsage(ctx context.Context, id []byte, signCount uint32, backupState bool) error {
+	query, args, err := Psql.
+		Update("webauthn_credentials").
+		Set("sign_count", int64(signCount)).
+		Set("backup_state", backupState).
+		Set("last_used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to update passkey usage: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PasskeyRepositoryImpl) FlagPasskeyClone(ctx context.Context, id []byte) error {
+	query, args, err := Psql.
+		Update("webauthn_credentials").
+		Set("clone_warning", true).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to flag passkey clone: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func scanPasskey(row rowScanner) (*entities.PasskeyCredential, error) {
+	var credential entities.PasskeyCredential
+	var signCount int64
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&credential.ID,
+		&credential.UserID,
+		&credential.PublicKey,
+		&credential.AttestationType,
+		pq.Array(&credential.Transports),
+		&credential.AAGUID,
+		&signCount,
+		&credential.BackupEligible,
+		&credential.BackupState,
+		&credential.CloneWarning,
+		&credential.CreatedAt,
+		&lastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	credential.SignCount = uint32(signCount)
+	if lastUsedAt.Valid {
+		credential.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &credential, nil
+}
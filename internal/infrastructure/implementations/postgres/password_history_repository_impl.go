@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type PasswordHistoryRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPasswordHistoryRepositoryImpl(db *sql.DB) repositories.PasswordHistoryRepository {
+	return &PasswordHistoryRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *PasswordHistoryRepositoryImpl) AddPasswordHistory(ctx context.Context, userID uuid.UUID, hashedPassword []byte) error {
+	query, args, err := Psql.
+		Insert("password_history").
+		Columns("user_id", "password").
+		Values(userID, hashedPassword).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert password history query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to insert password history: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PasswordHistoryRepositoryImpl) GetRecentPasswords(ctx context.Context, userID uuid.UUID, limit int) ([][]byte, error) {
+	query, args, err := Psql.
+		Select("password").
+		From("password_history").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("created_at DESC").
+		Limit(uint64(limit)).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing password history rows: %v", err)
+		}
+	}()
+
+	passwords := make([][]byte, 0, limit)
+	for rows.Next() {
+		var password []byte
+		if err = rows.Scan(&password); err != nil {
+			return nil, err
+		}
+		passwords = append(passwords, password)
+	}
+
+	return passwords, rows.Err()
+}
+
+func (r *PasswordHistoryRepositoryImpl) PrunePasswordHistory(ctx context.Context, userID uuid.UUID, keep int) error {
+	recent := squirrel.
+		Select("id").
+		From("password_history").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("created_at DESC").
+		Limit(uint64(keep))
+
+	recentSQL, recentArgs, err := recent.ToSql()
+	if err != nil {
+		return err
+	}
+
+	query, args, err := Psql.
+		Delete("password_history").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Expr("id NOT IN ("+recentSQL+")", recentArgs...)).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to prune password history: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type RecoveryCodeRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewRecoveryCodeRepositoryImpl(db *sql.DB) repositories.RecoveryCodeRepository {
+	return &RecoveryCodeRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *RecoveryCodeRepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes [][]byte) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Error rolling back recovery code replacement: %v", err)
+		}
+	}()
+
+	query, args, err := Psql.
+		Delete("mfa_recovery_codes").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		log.Printf("Failed to delete recovery codes: %v", err)
+		return err
+	}
+
+	if len(codeHashes) > 0 {
+		insert := Psql.
+			Insert("mfa_recovery_codes").
+			Columns("user_id", "code_hash")
+		for _, codeHash := range codeHashes {
+			insert = insert.Values(userID, codeHash)
+		}
+
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			log.Printf("Failed to insert recovery codes: %v", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *RecoveryCodeRepositoryImpl) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash []byte) (bool, error) {
+	query, args, err := Psql.
+		Update("mfa_recovery_codes").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"user_id":   userID,
+			"code_hash": codeHash,
+			"used_at":   nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to consume recovery code: %v", err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *RecoveryCodeRepositoryImpl) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	query, args, err := Psql.
+		Select("COUNT(*)").
+		From("mfa_recovery_codes").
+		Where(squirrel.Eq{
+			"user_id": userID,
+			"used_at": nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
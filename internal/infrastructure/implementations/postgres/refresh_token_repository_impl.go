@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepositoryImpl(db *sql.DB) repositories.RefreshTokenRepository {
+	return &RefreshTokenRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *RefreshTokenRepositoryImpl) InsertRefreshToken(ctx context.Context, token *entities.RefreshToken) error {
+	return insertRefreshToken(ctx, r.db, token)
+}
+
+func (r *RefreshTokenRepositoryImpl) GetRefreshToken(ctx context.Context, jti uuid.UUID) (*entities.RefreshToken, error) {
+	query, args, err := Psql.
+		Select("jti", "user_id", "family_id", "session_id", "parent_jti", "expires_at", "revoked_at", "used_at", "created_at").
+		From("refresh_tokens").
+		Where(squirrel.Eq{"jti": jti}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var token entities.RefreshToken
+	var sessionID, parentJTI uuid.NullUUID
+	var revokedAt, usedAt sql.NullTime
+
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&token.JTI,
+		&token.UserID,
+		&token.FamilyID,
+		&sessionID,
+		&parentJTI,
+		&token.ExpiresAt,
+		&revokedAt,
+		&usedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessionID.Valid {
+		token.SessionID = &sessionID.UUID
+	}
+	if parentJTI.Valid {
+		token.ParentJTI = &parentJTI.UUID
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return &token, nil
+}
+
+func (r *RefreshTokenRepositoryImpl) RotateRefreshToken(ctx context.Context, usedJTI uuid.UUID, next *entities.RefreshToken) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Error rolling back refresh token rotation: %v", err)
+		}
+	}()
+
+	query, args, err := Psql.
+		Update("refresh_tokens").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"jti":        usedJTI,
+			"used_at":    nil,
+			"revoked_at": nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if err = insertRefreshToken(ctx, tx, next); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query, args, err := Psql.
+		Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"family_id":  familyID,
+			"revoked_at": nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to revoke refresh token family: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	query, args, err := Psql.
+		Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"user_id":    userID,
+			"revoked_at": nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to revoke user refresh tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeSessionTokens(ctx context.Context, sessionID uuid.UUID) error {
+	query, args, err := Psql.
+		Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"session_id": sessionID,
+			"revoked_at": nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to revoke session refresh tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeOtherSessionTokens(ctx context.Context, userID uuid.UUID, keepSessionID uuid.UUID) error {
+	query, args, err := Psql.
+		Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"user_id":    userID,
+			"revoked_at": nil,
+		}).
+		Where(squirrel.Or{
+			squirrel.Eq{"session_id": nil},
+			squirrel.NotEq{"session_id": keepSessionID},
+		}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to revoke other session refresh tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertRefreshToken(ctx context.Context, db execer, token *entities.RefreshToken) error {
+	query, args, err := Psql.
+		Insert("refresh_tokens").
+		Columns("jti", "user_id", "family_id", "session_id", "parent_jti", "expires_at").
+		Values(token.JTI, token.UserID, token.FamilyID, token.SessionID, token.ParentJTI, token.ExpiresAt).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert refresh token query: %v", err)
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to insert refresh token: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type SessionRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewSessionRepositoryImpl(db *sql.DB) repositories.SessionRepository {
+	return &SessionRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *SessionRepositoryImpl) InsertSession(ctx context.Context, session *entities.Session) error {
+	query, args, err := Psql.
+		Insert("sessions").
+		Columns("id", "user_id", "user_agent", "ip_address").
+		Values(session.ID, session.UserID, session.UserAgent, session.IPAddress).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert session query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to insert session: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SessionRepositoryImpl) GetSession(ctx context.Context, id uuid.UUID) (*entities.Session, error) {
+	query, args, err := sessionSelect().
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSession(r.db.QueryRowContext(ctx, query, args...))
+}
+
+func (r *SessionRepositoryImpl) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]entities.Session, error) {
+	query, args, err := sessionSelect().
+		Where(squirrel.Eq{
+			"user_id":    userID,
+			"revoked_at": nil,
+		}).
+		OrderBy("last_refreshed_at DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing session rows: %v", err)
+		}
+	}()
+
+	sessions := make([]entities.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *SessionRepositoryImpl) TouchSession(ctx context.Context, id uuid.UUID) error {
+	query, args, err := Psql.
+		Update("sessions").
+		Set("last_refreshed_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *SessionRepositoryImpl) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	return r.revoke(ctx, squirrel.Eq{"id": id})
+}
+
+func (r *SessionRepositoryImpl) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return r.revoke(ctx, squirrel.Eq{"user_id": userID})
+}
+
+func (r *SessionRepositoryImpl) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keepID uuid.UUID) error {
+	return r.revoke(ctx, squirrel.Eq{"user_id": userID}, squirrel.NotEq{"id": keepID})
+}
+
+func (r *SessionRepositoryImpl) revoke(ctx context.Context, where ...squirrel.Sqlizer) error {
+	query, args, err := Psql.
+		Update("sessions").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(append(squirrel.And{squirrel.Eq{"revoked_at": nil}}, where...)).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to revoke sessions: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func sessionSelect() squirrel.SelectBuilder {
+	return Psql.
+		Select("id", "user_id", "user_agent", "ip_address", "created_at", "last_refreshed_at", "revoked_at").
+		From("sessions")
+}
+
+func scanSession(row rowScanner) (*entities.Session, error) {
+	var session entities.Session
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastRefreshedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return &session, nil
+}
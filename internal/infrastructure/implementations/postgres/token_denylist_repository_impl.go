@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type TokenDenylistRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewTokenDenylistRepositoryImpl(db *sql.DB) repositories.TokenDenylistRepository {
+	return &TokenDenylistRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *TokenDenylistRepositoryImpl) DenyToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	query, args, err := Psql.
+		Insert("revoked_access_tokens").
+		Columns("jti", "expires_at").
+		Values(jti, expiresAt).
+		Suffix("ON CONFLICT (jti) DO NOTHING").
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build deny token query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to deny token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *TokenDenylistRepositoryImpl) IsTokenDenied(ctx context.Context, jti uuid.UUID) (bool, error) {
+	query, args, err := Psql.
+		Select("1").
+		From("revoked_access_tokens").
+		Where(squirrel.Eq{"jti": jti}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var denied int
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&denied)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *TokenDenylistRepositoryImpl) DeleteExpiredTokens(ctx context.Context) error {
+	query, args, err := Psql.
+		Delete("revoked_access_tokens").
+		Where(squirrel.Lt{"expires_at": time.Now()}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to delete expired denied tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
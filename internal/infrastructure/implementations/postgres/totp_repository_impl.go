@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type TOTPRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewTOTPRepositoryImpl(db *sql.DB) repositories.TOTPRepository {
+	return &TOTPRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *TOTPRepositoryImpl) SavePendingTOTP(ctx context.Context, userID uuid.UUID, encryptedSecret []byte) (bool, error) {
+	query, args, err := Psql.
+		Insert("user_totp").
+		Columns("user_id", "secret").
+		Values(userID, encryptedSecret).
+		Suffix(
+			"ON CONFLICT (user_id) DO UPDATE SET " +
+				"secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW() " +
+				"WHERE user_totp.confirmed_at IS NULL",
+		).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build save totp query: %v", err)
+		return false, err
+	}
+
+	return r.execAffected(ctx, "save pending totp", query, args...)
+}
+
+func (r *TOTPRepositoryImpl) GetTOTP(ctx context.Context, userID uuid.UUID) (*entities.TOTPCredential, error) {
+	query, args, err := Psql.
+		Select("user_id", "secret", "confirmed_at", "last_used_step", "created_at").
+		From("user_totp").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var credential entities.TOTPCredential
+	var confirmedAt sql.NullTime
+
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&credential.UserID,
+		&credential.Secret,
+		&confirmedAt,
+		&credential.LastUsedStep,
+		&credential.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if confirmedAt.Valid {
+		credential.ConfirmedAt = &confirmedAt.Time
+	}
+
+	return &credential, nil
+}
+
+func (r *TOTPRepositoryImpl) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query, args, err := Psql.
+		Update("user_totp").
+		Set("confirmed_at", squirrel.Expr("NOW()")).
+		Set("last_used_step", step).
+		Where(squirrel.Eq{
+			"user_id":      userID,
+			"confirmed_at": nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	return r.execAffected(ctx, "confirm totp", query, args...)
+}
+
+func (r *TOTPRepositoryImpl) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query, args, err := Psql.
+		Update("user_totp").
+		Set("last_used_step", step).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.NotEq{"confirmed_at": nil}).
+		Where(squirrel.Lt{"last_used_step": step}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	return r.execAffected(ctx, "use totp step", query, args...)
+}
+
+func (r *TOTPRepositoryImpl) execAffected(ctx context.Context, action string, query string, args ...interface{}) (bool, error) {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to %s: %v", action, err)
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
 
+	"authService/internal/domain/entities"
 	"authService/internal/domain/repositories"
+	"authService/internal/utils/service_errors"
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const uniqueViolation = "23505"
+
 var Psql = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
 type UserRepositoryImpl struct {
@@ -23,32 +29,38 @@ func NewUserRepositoryImpl(db *sql.DB) repositories.UserRepository {
 	}
 }
 
-func (r *UserRepositoryImpl) InsertUser(ctx context.Context, email string, hashedPassword []byte) error {
+func (r *UserRepositoryImpl) InsertUser(ctx context.Context, email string, normalizedEmail string, hashedPassword []byte) (uuid.UUID, error) {
 	query, args, err := Psql.
 		Insert("users").
-		Columns("email", "password", "is_active").
-		Values(email, hashedPassword, true).
+		Columns("email", "email_normalized", "password", "is_active").
+		Values(email, normalizedEmail, hashedPassword, false).
+		Suffix("RETURNING id").
 		ToSql()
 
 	if err != nil {
 		log.Printf("Failed to build insert user query: %v", err)
-		return err
+		return uuid.Nil, err
 	}
 
-	_, err = r.db.ExecContext(ctx, query, args...)
+	var id uuid.UUID
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&id)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return uuid.Nil, service_errors.UserAlreadyExistsError
+		}
 		log.Printf("Failed to insert user: %v", err)
-		return err
+		return uuid.Nil, err
 	}
 
-	return nil
+	return id, nil
 }
 
-func (r *UserRepositoryImpl) CheckUserExist(ctx context.Context, email string) (bool, error) {
+func (r *UserRepositoryImpl) CheckUserExist(ctx context.Context, normalizedEmail string) (bool, error) {
 	query, args, err := Psql.
 		Select("1").
 		From("users").
-		Where(squirrel.Eq{"email": email}).
+		Where(squirrel.Eq{"email_normalized": normalizedEmail}).
 		ToSql()
 
 	if err != nil {
@@ -68,14 +80,11 @@ func (r *UserRepositoryImpl) CheckUserExist(ctx context.Context, email string) (
 	return true, nil
 }
 
-func (r *UserRepositoryImpl) GetUserCredentials(ctx context.Context, email string) (uuid.UUID, []byte, error) {
+func (r *UserRepositoryImpl) GetUserCredentials(ctx context.Context, normalizedEmail string) (uuid.UUID, []byte, error) {
 	query, args, err := Psql.
 		Select("id", "password").
 		From("users").
-		Where(squirrel.Eq{
-			"email":     email,
-			"is_active": true,
-		}).
+		Where(squirrel.Eq{"email_normalized": normalizedEmail}).
 		ToSql()
 
 	if err != nil {
@@ -92,3 +101,238 @@ func (r *UserRepositoryImpl) GetUserCredentials(ctx context.Context, email strin
 
 	return id, pwdHash, nil
 }
+
+func (r *UserRepositoryImpl) GetPasswordHash(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	query, args, err := Psql.
+		Select("password").
+		From("users").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var pwdHash []byte
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&pwdHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return pwdHash, nil
+}
+
+func (r *UserRepositoryImpl) GetUserByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	return r.getUser(ctx, squirrel.Eq{"id": id})
+}
+
+func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, normalizedEmail string) (*entities.User, error) {
+	return r.getUser(ctx, squirrel.Eq{"email_normalized": normalizedEmail})
+}
+
+func (r *UserRepositoryImpl) ConfirmEmail(ctx context.Context, id uuid.UUID) error {
+	query, args, err := Psql.
+		Update("users").
+		Set("is_active", true).
+		Set("email_confirmed_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"id":                 id,
+			"email_confirmed_at": nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to confirm email: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *UserRepositoryImpl) IncrementTokenVersion(ctx context.Context, id uuid.UUID) error {
+	query, args, err := Psql.
+		Update("users").
+		Set("token_version", squirrel.Expr("token_version + 1")).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to increment token version: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *UserRepositoryImpl) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword []byte) error {
+	query, args, err := Psql.
+		Update("users").
+		Set("password", hashedPassword).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to update password: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// BackfillNormalizedEmails recomputes email_normalized with normalize and
+// returns the number of updated accounts. It fails without changes when two
+// accounts collapse to the same normalized email.
+func (r *UserRepositoryImpl) BackfillNormalizedEmails(ctx context.Context, normalize func(string) string) (int, error) {
+	stale, err := staleNormalizedEmails(ctx, r.db, normalize)
+	if err != nil || len(stale) == 0 {
+		return 0, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			log.Printf("Error rolling back email backfill: %v", err)
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, "LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return 0, err
+	}
+
+	stale, err = staleNormalizedEmails(ctx, tx, normalize)
+	if err != nil {
+		return 0, err
+	}
+
+	for id, normalized := range stale {
+		query, args, err := Psql.
+			Update("users").
+			Set("email_normalized", normalized).
+			Where(squirrel.Eq{"id": id}).
+			ToSql()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			log.Printf("Failed to backfill normalized email: %v", err)
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(stale), nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func staleNormalizedEmails(ctx context.Context, db queryer, normalize func(string) string) (map[uuid.UUID]string, error) {
+	query, args, err := Psql.
+		Select("id", "email", "email_normalized").
+		From("users").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	owners := make(map[string]uuid.UUID)
+	stale := make(map[uuid.UUID]string)
+	var conflicts int
+	var example string
+	for rows.Next() {
+		var id uuid.UUID
+		var email string
+		var stored sql.NullString
+		if err = rows.Scan(&id, &email, &stored); err != nil {
+			return nil, err
+		}
+
+		normalized := normalize(email)
+		if _, taken := owners[normalized]; taken {
+			conflicts++
+			example = normalized
+			continue
+		}
+		owners[normalized] = id
+		if stored.String != normalized {
+			stale[id] = normalized
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if conflicts > 0 {
+		return nil, fmt.Errorf(
+			"%d accounts share a normalized email with another account (e.g. %s): merge them before starting, see cmd/email-dedupe",
+			conflicts, example,
+		)
+	}
+
+	return stale, nil
+}
+
+func (r *UserRepositoryImpl) getUser(ctx context.Context, where squirrel.Eq) (*entities.User, error) {
+	query, args, err := Psql.
+		Select("id", "email", "is_active", "token_version", "email_confirmed_at", "created_at", "updated_at").
+		From("users").
+		Where(where).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var user entities.User
+	var emailConfirmedAt sql.NullTime
+
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&user.ID,
+		&user.Email,
+		&user.IsActive,
+		&user.TokenVersion,
+		&emailConfirmedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if emailConfirmedAt.Valid {
+		user.EmailConfirmedAt = &emailConfirmedAt.Time
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type VerificationTokenRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewVerificationTokenRepositoryImpl(db *sql.DB) repositories.VerificationTokenRepository {
+	return &VerificationTokenRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *VerificationTokenRepositoryImpl) InsertVerificationToken(ctx context.Context, token *entities.VerificationToken) error {
+	query, args, err := Psql.
+		Insert("verification_tokens").
+		Columns("id", "user_id", "purpose", "token_hash", "expires_at").
+		Values(token.ID, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert verification token query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to insert verification token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *VerificationTokenRepositoryImpl) GetActiveVerificationToken(ctx context.Context, purpose string, tokenHash []byte) (*entities.VerificationToken, error) {
+	query, args, err := Psql.
+		Select("id", "user_id", "purpose", "token_hash", "expires_at", "used_at", "created_at").
+		From("verification_tokens").
+		Where(squirrel.Eq{
+			"purpose":    purpose,
+			"token_hash": tokenHash,
+			"used_at":    nil,
+		}).
+		Where(squirrel.Expr("expires_at > NOW()")).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanVerificationToken(r.db.QueryRowContext(ctx, query, args...))
+}
+
+func (r *VerificationTokenRepositoryImpl) GetLatestActiveToken(ctx context.Context, userID uuid.UUID, purpose string) (*entities.VerificationToken, error) {
+	query, args, err := Psql.
+		Select("id", "user_id", "purpose", "token_hash", "expires_at", "used_at", "created_at").
+		From("verification_tokens").
+		Where(squirrel.Eq{
+			"user_id": userID,
+			"purpose": purpose,
+			"used_at": nil,
+		}).
+		Where(squirrel.Expr("expires_at > NOW()")).
+		OrderBy("created_at DESC").
+		Limit(1).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanVerificationToken(r.db.QueryRowContext(ctx, query, args...))
+}
+
+func (r *VerificationTokenRepositoryImpl) ConsumeVerificationToken(ctx context.Context, purpose string, tokenHash []byte) (*entities.VerificationToken, error) {
+	query, args, err := Psql.
+		Update("verification_tokens").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"purpose":    purpose,
+			"token_hash": tokenHash,
+			"used_at":    nil,
+		}).
+		Where(squirrel.Expr("expires_at > NOW()")).
+		Suffix("RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanVerificationToken(r.db.QueryRowContext(ctx, query, args...))
+}
+
+func (r *VerificationTokenRepositoryImpl) InvalidateUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error {
+	query, args, err := Psql.
+		Update("verification_tokens").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{
+			"user_id": userID,
+			"purpose": purpose,
+			"used_at": nil,
+		}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to invalidate verification tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *VerificationTokenRepositoryImpl) LastIssuedAt(ctx context.Context, userID uuid.UUID, purpose string) (time.Time, error) {
+	query, args, err := Psql.
+		Select("COALESCE(MAX(created_at), 'epoch'::timestamptz)").
+		From("verification_tokens").
+		Where(squirrel.Eq{
+			"user_id": userID,
+			"purpose": purpose,
+		}).
+		ToSql()
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var issuedAt time.Time
+	if err = r.db.QueryRowContext(ctx, query, args...).Scan(&issuedAt); err != nil {
+		return time.Time{}, err
+	}
+
+	return issuedAt, nil
+}
+
+func (r *VerificationTokenRepositoryImpl) DeleteExpiredTokens(ctx context.Context) error {
+	query, args, err := Psql.
+		Delete("verification_tokens").
+		Where(squirrel.Expr("expires_at < NOW()")).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func scanVerificationToken(row rowScanner) (*entities.VerificationToken, error) {
+	var token entities.VerificationToken
+	var usedAt sql.NullTime
+
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return &token, nil
+}
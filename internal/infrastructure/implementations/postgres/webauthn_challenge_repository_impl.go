@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+type WebAuthnChallengeRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewWebAuthnChallengeRepositoryImpl(db *sql.DB) repositories.WebAuthnChallengeRepository {
+	return &WebAuthnChallengeRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *WebAuthnChallengeRepositoryImpl) InsertChallenge(ctx context.Context, challenge *entities.WebAuthnChallenge) error {
+	query, args, err := Psql.
+		Insert("webauthn_challenges").
+		Columns("id", "user_id", "purpose", "session_data", "expires_at").
+		Values(challenge.ID, challenge.UserID, challenge.Purpose, challenge.SessionData, challenge.ExpiresAt).
+		ToSql()
+
+	if err != nil {
+		log.Printf("Failed to build insert webauthn challenge query: %v", err)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to insert webauthn challenge: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *WebAuthnChallengeRepositoryImpl) ConsumeChallenge(ctx context.Context, id uuid.UUID, purpose string) (*entities.WebAuthnChallenge, error) {
+	query, args, err := Psql.
+		Delete("webauthn_challenges").
+		Where(squirrel.Eq{
+			"id":      id,
+			"purpose": purpose,
+		}).
+		Where(squirrel.Expr("expires_at > NOW()")).
+		Suffix("RETURNING id, user_id, purpose, session_data, expires_at, created_at").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var challenge entities.WebAuthnChallenge
+	var userID uuid.NullUUID
+
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&challenge.ID,
+		&userID,
+		&challenge.Purpose,
+		&challenge.SessionData,
+		&challenge.ExpiresAt,
+		&challenge.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		challenge.UserID = &userID.UUID
+	}
+
+	return &challenge, nil
+}
+
+func (r *WebAuthnChallengeRepositoryImpl) DeleteExpiredChallenges(ctx context.Context) error {
+	query, args, err := Psql.
+		Delete("webauthn_challenges").
+		Where(squirrel.Expr("expires_at < NOW()")).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -7,8 +7,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// StartMetricsServer serves only /metrics on its own mux; the OAuth and admin
+// endpoints run on a separate listener and never share this plain HTTP port.
 func StartMetricsServer(port string) {
-	http.Handle("/metrics", promhttp.HandlerFor(
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(
 		customRegistry,
 		promhttp.HandlerOpts{
 			Registry: customRegistry,
@@ -16,7 +19,7 @@ func StartMetricsServer(port string) {
 	))
 
 	log.Printf("Metrics server starting on :%s", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
 		log.Printf("Metrics server error: %v", err)
 	}
 }
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"authService/internal/domain/repositories"
+)
+
+func StartTokenCleanup(
+	ctx context.Context,
+	denylistRepo repositories.TokenDenylistRepository,
+	verificationRepo repositories.VerificationTokenRepository,
+	loginAttemptRepo repositories.LoginAttemptRepository,
+	challengeRepo repositories.WebAuthnChallengeRepository,
+	authCodeRepo repositories.AuthorizationCodeRepository,
+	attemptRetention time.Duration,
+	interval time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := denylistRepo.DeleteExpiredTokens(ctx); err != nil {
+				log.Printf("Error cleaning up denied tokens: %v", err)
+			}
+			if err := verificationRepo.DeleteExpiredTokens(ctx); err != nil {
+				log.Printf("Error cleaning up verification tokens: %v", err)
+			}
+			if err := loginAttemptRepo.DeleteStaleAttempts(ctx, attemptRetention); err != nil {
+				log.Printf("Error cleaning up login attempts: %v", err)
+			}
+			if err := challengeRepo.DeleteExpiredChallenges(ctx); err != nil {
+				log.Printf("Error cleaning up passkey challenges: %v", err)
+			}
+			if err := authCodeRepo.DeleteExpiredAuthorizationCodes(ctx); err != nil {
+				log.Printf("Error cleaning up authorization codes: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+	"github.com/google/uuid"
+)
+
+func (u *UserServiceImpl) ConfirmEmail(ctx context.Context, token string) error {
+	stored, err := u.verificationRepo.ConsumeVerificationToken(ctx, entities.VerificationPurposeEmailConfirm, hashing.HashVerificationToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service_errors.InvalidTokenError
+		}
+		log.Printf("Error consuming confirmation token: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err = u.userRepo.ConfirmEmail(ctx, stored.UserID); err != nil {
+		log.Printf("Error confirming email: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	log.Printf("Email confirmed for user %s", stored.UserID)
+	return nil
+}
+
+func (u *UserServiceImpl) ResendConfirmation(ctx context.Context, cfg *config.Config, email string) error {
+	user, err := u.userRepo.GetUserByEmail(ctx, cfg.Email.Normalize(email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		log.Printf("Error getting user: %v", err)
+		return service_errors.InternalServerError
+	}
+	if user.EmailConfirmedAt != nil {
+		return nil
+	}
+
+	issuedAt, err := u.verificationRepo.LastIssuedAt(ctx, user.ID, entities.VerificationPurposeEmailConfirm)
+	if err != nil {
+		log.Printf("Error getting last confirmation token: %v", err)
+		return service_errors.InternalServerError
+	}
+	// Throttled silently: an error here would only be returned for existing
+	// unconfirmed accounts and reveal them.
+	if time.Since(issuedAt) < cfg.Email.ResendCooldown() {
+		log.Printf("Confirmation for user %s requested too often, skipping", user.ID)
+		return nil
+	}
+
+	if err = u.verificationRepo.InvalidateUserTokens(ctx, user.ID, entities.VerificationPurposeEmailConfirm); err != nil {
+		log.Printf("Error invalidating confirmation tokens: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	return u.sendConfirmation(ctx, cfg, user.ID, user.Email)
+}
+
+func (u *UserServiceImpl) sendConfirmation(ctx context.Context, cfg *config.Config, userID uuid.UUID, email string) error {
+	return u.sendVerificationEmail(
+		ctx,
+		userID,
+		email,
+		entities.VerificationPurposeEmailConfirm,
+		cfg.BrokerConstants.EmailConfirm,
+		cfg.Email.ConfirmTTL(),
+	)
+}
+
+func (u *UserServiceImpl) sendVerificationEmail(
+	ctx context.Context,
+	userID uuid.UUID,
+	email string,
+	purpose string,
+	queue string,
+	ttl time.Duration,
+) error {
+	token, tokenHash, err := hashing.GenerateVerificationToken()
+	if err != nil {
+		log.Printf("Error generating %s token: %v", purpose, err)
+		return service_errors.InternalServerError
+	}
+
+	stored := &entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err = u.verificationRepo.InsertVerificationToken(ctx, stored); err != nil {
+		log.Printf("Error storing %s token: %v", purpose, err)
+		return service_errors.InternalServerError
+	}
+
+	message := value_objects.EmailMessage{
+		Type:      purpose,
+		Email:     email,
+		Token:     token,
+		ExpiresAt: stored.ExpiresAt,
+	}
+	u.publishEmail(queue, message)
+
+	return nil
+}
+
+// publishEmail hands the message to the broker in the background. A broker
+// outage is only logged and must not take the server down.
+func (u *UserServiceImpl) publishEmail(queue string, message value_objects.EmailMessage) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Panic publishing %s message: %v", message.Type, r)
+			}
+		}()
+		if err := u.brokerRepo.CreateEmailMSG(queue, message); err != nil {
+			log.Printf("Error publishing %s message: %v", message.Type, err)
+		}
+	}()
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"authService/internal/domain/entities"
+)
+
+func TestResendConfirmationDoesNotRevealAccounts(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	s.cfg.Email.ResendCooldownSeconds = 60
+	s.cfg.Email.ConfirmExpireHours = 24
+
+	s.addUser("confirmed@example.com")
+	unconfirmed := s.addUser("unconfirmed@example.com")
+	unconfirmed.IsActive = false
+	unconfirmed.EmailConfirmedAt = nil
+
+	tests := []struct {
+		name       string
+		email      string
+		wantTokens int
+	}{
+		{name: "unknown address", email: "nobody@example.com", wantTokens: 0},
+		{name: "confirmed account", email: "confirmed@example.com", wantTokens: 0},
+		{name: "unconfirmed account", email: "unconfirmed@example.com", wantTokens: 1},
+		{name: "within cooldown", email: "unconfirmed@example.com", wantTokens: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.ResendConfirmation(ctx, s.cfg, tt.email); err != nil {
+				t.Fatalf("ResendConfirmation(%s): %v", tt.email, err)
+			}
+			if issued := countTokens(s.tokens, entities.VerificationPurposeEmailConfirm); issued != tt.wantTokens {
+				t.Errorf("confirmation tokens issued = %d, want %d", issued, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func countTokens(repo *fakeVerificationTokenRepository, purpose string) int {
+	count := 0
+	for _, token := range repo.tokens {
+		if token.Purpose == purpose {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/repositories"
+	"authService/internal/domain/value_objects"
+	"authService/internal/infrastructure/implementations/memory"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The fakes embed the repository interfaces, so a call the test does not
+// expect panics instead of silently succeeding.
+
+type fakeUserRepository struct {
+	repositories.UserRepository
+	users           map[uuid.UUID]*entities.User
+	passwords       map[uuid.UUID][]byte
+	passwordUpdates int
+}
+
+func (r *fakeUserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetUserByEmail(ctx context.Context, normalizedEmail string) (*entities.User, error) {
+	for _, user := range r.users {
+		if user.Email == normalizedEmail {
+			return user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeUserRepository) GetUserCredentials(ctx context.Context, normalizedEmail string) (uuid.UUID, []byte, error) {
+	user, err := r.GetUserByEmail(ctx, normalizedEmail)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return user.ID, r.passwords[user.ID], nil
+}
+
+func (r *fakeUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword []byte) error {
+	r.passwords[id] = hashedPassword
+	r.passwordUpdates++
+	return nil
+}
+
+func (r *fakeUserRepository) GetPasswordHash(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	hash, ok := r.passwords[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return hash, nil
+}
+
+type fakeLoginAttemptRepository struct {
+	repositories.LoginAttemptRepository
+	mu       sync.Mutex
+	attempts map[string]*entities.LoginAttempt
+}
+
+func (r *fakeLoginAttemptRepository) GetLoginAttempt(ctx context.Context, key string) (*entities.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *attempt
+	return &copied, nil
+}
+
+func (r *fakeLoginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		attempt = &entities.LoginAttempt{Key: key}
+		r.attempts[key] = attempt
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = time.Now()
+	return attempt.Failures, nil
+}
+
+func (r *fakeLoginAttemptRepository) LockUntil(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok {
+		attempt.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) failures(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok {
+		return attempt.Failures
+	}
+	return 0
+}
+
+type fakeTOTPRepository struct {
+	repositories.TOTPRepository
+	credentials map[uuid.UUID]*entities.TOTPCredential
+}
+
+func (r *fakeTOTPRepository) GetTOTP(ctx context.Context, userID uuid.UUID) (*entities.TOTPCredential, error) {
+	credential, ok := r.credentials[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return credential, nil
+}
+
+func (r *fakeTOTPRepository) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	credential, ok := r.credentials[userID]
+	if !ok || step <= credential.LastUsedStep {
+		return false, nil
+	}
+	credential.LastUsedStep = step
+	return true, nil
+}
+
+type fakeRecoveryCodeRepository struct {
+	repositories.RecoveryCodeRepository
+	codes map[uuid.UUID][][]byte
+}
+
+func (r *fakeRecoveryCodeRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes [][]byte) error {
+	r.codes[userID] = codeHashes
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepository) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash []byte) (bool, error) {
+	for i, stored := range r.codes[userID] {
+		if bytes.Equal(stored, codeHash) {
+			r.codes[userID] = append(r.codes[userID][:i:i], r.codes[userID][i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRecoveryCodeRepository) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	return len(r.codes[userID]), nil
+}
+
+type fakeVerificationTokenRepository struct {
+	repositories.VerificationTokenRepository
+	tokens []*entities.VerificationToken
+}
+
+func (r *fakeVerificationTokenRepository) InsertVerificationToken(ctx context.Context, token *entities.VerificationToken) error {
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakeVerificationTokenRepository) LastIssuedAt(ctx context.Context, userID uuid.UUID, purpose string) (time.Time, error) {
+	var issuedAt time.Time
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.CreatedAt.After(issuedAt) {
+			issuedAt = token.CreatedAt
+		}
+	}
+	return issuedAt, nil
+}
+
+func (r *fakeVerificationTokenRepository) GetLatestActiveToken(ctx context.Context, userID uuid.UUID, purpose string) (*entities.VerificationToken, error) {
+	for i := len(r.tokens) - 1; i >= 0; i-- {
+		token := r.tokens[i]
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil && time.Now().Before(token.ExpiresAt) {
+			return token, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeVerificationTokenRepository) ConsumeVerificationToken(ctx context.Context, purpose string, tokenHash []byte) (*entities.VerificationToken, error) {
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.UsedAt == nil && bytes.Equal(token.TokenHash, tokenHash) {
+			usedAt := time.Now()
+			token.UsedAt = &usedAt
+			return token, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeVerificationTokenRepository) InvalidateUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			usedAt := time.Now()
+			token.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+type fakeSessionRepository struct {
+	repositories.SessionRepository
+	sessions map[uuid.UUID]*entities.Session
+}
+
+func (r *fakeSessionRepository) InsertSession(ctx context.Context, session *entities.Session) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *fakeSessionRepository) GetSession(ctx context.Context, id uuid.UUID) (*entities.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+func (r *fakeSessionRepository) TouchSession(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+type fakeRefreshTokenRepository struct {
+	repositories.RefreshTokenRepository
+	tokens map[uuid.UUID]*entities.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepository) InsertRefreshToken(ctx context.Context, token *entities.RefreshToken) error {
+	r.tokens[token.JTI] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) GetRefreshToken(ctx context.Context, jti uuid.UUID) (*entities.RefreshToken, error) {
+	token, ok := r.tokens[jti]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return token, nil
+}
+
+func (r *fakeRefreshTokenRepository) RotateRefreshToken(ctx context.Context, usedJTI uuid.UUID, next *entities.RefreshToken) (bool, error) {
+	used, ok := r.tokens[usedJTI]
+	if !ok || used.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	used.UsedAt = &now
+	r.tokens[next.JTI] = next
+	return true, nil
+}
+
+type fakePasskeyRepository struct {
+	repositories.PasskeyRepository
+	credentials map[string]*entities.PasskeyCredential
+}
+
+func (r *fakePasskeyRepository) InsertPasskey(ctx context.Context, credential *entities.PasskeyCredential) error {
+	if _, ok := r.credentials[string(credential.ID)]; ok {
+		return service_errors.PasskeyAlreadyRegisteredError
+	}
+	r.credentials[string(credential.ID)] = credential
+	return nil
+}
+
+func (r *fakePasskeyRepository) GetPasskey(ctx context.Context, id []byte) (*entities.PasskeyCredential, error) {
+	credential, ok := r.credentials[string(id)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return credential, nil
+}
+
+func (r *fakePasskeyRepository) ListUserPasskeys(ctx context.Context, userID uuid.UUID) ([]entities.PasskeyCredential, error) {
+	var credentials []entities.PasskeyCredential
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, *credential)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *fakePasskeyRepository) UpdatePasskeyUsage(ctx context.Context, id []byte, signCount uint32, backupState bool) error {
+	if credential, ok := r.credentials[string(id)]; ok {
+		credential.SignCount = signCount
+		credential.BackupState = backupState
+	}
+	return nil
+}
+
+type fakeWebAuthnChallengeRepository struct {
+	repositories.WebAuthnChallengeRepository
+	challenges map[uuid.UUID]*entities.WebAuthnChallenge
+}
+
+func (r *fakeWebAuthnChallengeRepository) InsertChallenge(ctx context.Context, challenge *entities.WebAuthnChallenge) error {
+	r.challenges[challenge.ID] = challenge
+	return nil
+}
+
+func (r *fakeWebAuthnChallengeRepository) ConsumeChallenge(ctx context.Context, id uuid.UUID, purpose string) (*entities.WebAuthnChallenge, error) {
+	challenge, ok := r.challenges[id]
+	if !ok || challenge.Purpose != purpose || time.Now().After(challenge.ExpiresAt) {
+		return nil, sql.ErrNoRows
+	}
+	delete(r.challenges, id)
+	return challenge, nil
+}
+
+type fakeOAuthClientRepository struct {
+	repositories.OAuthClientRepository
+	clients  map[string]*entities.OAuthClient
+	consents map[string]bool
+}
+
+func (r *fakeOAuthClientRepository) InsertClient(ctx context.Context, client *entities.OAuthClient) error {
+	if _, ok := r.clients[client.ID]; ok {
+		return service_errors.OAuthClientAlreadyExistsError
+	}
+	r.clients[client.ID] = client
+	return nil
+}
+
+func (r *fakeOAuthClientRepository) GetClient(ctx context.Context, id string) (*entities.OAuthClient, error) {
+	client, ok := r.clients[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return client, nil
+}
+
+func (r *fakeOAuthClientRepository) HasConsent(ctx context.Context, userID uuid.UUID, clientID string) (bool, error) {
+	return r.consents[userID.String()+"/"+clientID], nil
+}
+
+func (r *fakeOAuthClientRepository) InsertConsent(ctx context.Context, userID uuid.UUID, clientID string) error {
+	r.consents[userID.String()+"/"+clientID] = true
+	return nil
+}
+
+type fakeAuthorizationCodeRepository struct {
+	repositories.AuthorizationCodeRepository
+	codes []*entities.AuthorizationCode
+}
+
+func (r *fakeAuthorizationCodeRepository) InsertAuthorizationCode(ctx context.Context, code *entities.AuthorizationCode) error {
+	r.codes = append(r.codes, code)
+	return nil
+}
+
+type fakeBrokerRepository struct{}
+
+func (fakeBrokerRepository) CreateEmailMSG(queue string, message value_objects.EmailMessage) error {
+	return nil
+}
+
+type testService struct {
+	*UserServiceImpl
+	cfg      *config.Config
+	users    *fakeUserRepository
+	attempts *fakeLoginAttemptRepository
+	totp     *fakeTOTPRepository
+	recovery *fakeRecoveryCodeRepository
+	passkeys *fakePasskeyRepository
+	tokens   *fakeVerificationTokenRepository
+	oauth    *fakeOAuthClientRepository
+	codes    *fakeAuthorizationCodeRepository
+}
+
+func newTestService(t *testing.T) *testService {
+	t.Helper()
+
+	// The lowest cost keeps the tests fast; it is not what production uses.
+	bcryptHasher, err := hashing.NewBcryptHasher(bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("NewBcryptHasher: %v", err)
+	}
+	signingKey, err := hashing.NewHMACSigningKey("test", []byte("test-secret"))
+	if err != nil {
+		t.Fatalf("NewHMACSigningKey: %v", err)
+	}
+	secretBox, err := hashing.NewSecretBox(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatalf("NewSecretBox: %v", err)
+	}
+
+	s := &testService{
+		cfg: &config.Config{
+			JWT: config.JWTConfig{
+				AccessExpireMinutes: 15,
+				RefreshExpireDays:   30,
+				Issuer:              "http://auth.test",
+				Audiences:           []string{"web-app"},
+			},
+			Lockout: config.LockoutConfig{
+				Threshold:       3,
+				DurationMinutes: 15,
+				WindowMinutes:   15,
+			},
+			MFA: config.MFAConfig{
+				ChallengeTTLSeconds: 300,
+				TOTPSkew:            1,
+				MaxAttempts:         5,
+				RecoveryCodes:       4,
+			},
+		},
+		users: &fakeUserRepository{
+			users:     make(map[uuid.UUID]*entities.User),
+			passwords: make(map[uuid.UUID][]byte),
+		},
+		attempts: &fakeLoginAttemptRepository{attempts: make(map[string]*entities.LoginAttempt)},
+		totp:     &fakeTOTPRepository{credentials: make(map[uuid.UUID]*entities.TOTPCredential)},
+		recovery: &fakeRecoveryCodeRepository{codes: make(map[uuid.UUID][][]byte)},
+		passkeys: &fakePasskeyRepository{credentials: make(map[string]*entities.PasskeyCredential)},
+		tokens:   &fakeVerificationTokenRepository{},
+		oauth: &fakeOAuthClientRepository{
+			clients:  make(map[string]*entities.OAuthClient),
+			consents: make(map[string]bool),
+		},
+		codes: &fakeAuthorizationCodeRepository{},
+	}
+	s.UserServiceImpl = &UserServiceImpl{
+		userRepo:         s.users,
+		brokerRepo:       fakeBrokerRepository{},
+		refreshTokenRepo: &fakeRefreshTokenRepository{tokens: make(map[uuid.UUID]*entities.RefreshToken)},
+		denylistRepo:     memory.NewTokenDenylistRepositoryImpl(),
+		sessionRepo:      &fakeSessionRepository{sessions: make(map[uuid.UUID]*entities.Session)},
+		verificationRepo: s.tokens,
+		loginAttemptRepo: s.attempts,
+		totpRepo:         s.totp,
+		recoveryCodeRepo: s.recovery,
+		passkeyRepo:      s.passkeys,
+		challengeRepo:    &fakeWebAuthnChallengeRepository{challenges: make(map[uuid.UUID]*entities.WebAuthnChallenge)},
+		oauthClientRepo:  s.oauth,
+		authCodeRepo:     s.codes,
+		passwords:        hashing.NewPasswordHashers(bcryptHasher),
+		keys:             hashing.NewKeyring(signingKey, time.Hour),
+		secretBox:        secretBox,
+	}
+
+	return s
+}
+
+func (s *testService) addUser(email string) *entities.User {
+	confirmedAt := time.Now()
+	user := &entities.User{
+		ID:               uuid.New(),
+		Email:            email,
+		IsActive:         true,
+		EmailConfirmedAt: &confirmedAt,
+	}
+	s.users.users[user.ID] = user
+	return user
+}
+
+func (s *testService) setPassword(t *testing.T, user *entities.User, password string) {
+	t.Helper()
+
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	s.users.passwords[user.ID] = hash
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+)
+
+const accountLockedEvent = "account_locked"
+
+func (u *UserServiceImpl) checkLoginLock(ctx context.Context, email string, ipAddress string) error {
+	for _, key := range loginAttemptKeys(email, ipAddress) {
+		attempt, err := u.loginAttemptRepo.GetLoginAttempt(ctx, key)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			log.Printf("Error getting login attempts: %v", err)
+			return service_errors.InternalServerError
+		}
+
+		if attempt.LockedUntil != nil && time.Now().Before(*attempt.LockedUntil) {
+			return &service_errors.RetryAfterError{
+				Err:        service_errors.AccountLockedError,
+				RetryAfter: time.Until(*attempt.LockedUntil),
+			}
+		}
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) loginFailed(ctx context.Context, cfg *config.Config, email string, ipAddress string, userExists bool) error {
+	accountKey := accountAttemptKey(email)
+	failures, err := u.loginAttemptRepo.RecordFailure(ctx, accountKey, cfg.Lockout.Window())
+	if err != nil {
+		log.Printf("Error recording login failure: %v", err)
+		return service_errors.InvalidCredentialsError
+	}
+
+	if delay := cfg.Lockout.Delay(failures, cfg.Lockout.Threshold); delay > 0 {
+		lockedUntil := time.Now().Add(delay)
+		if err = u.loginAttemptRepo.LockUntil(ctx, accountKey, lockedUntil); err != nil {
+			log.Printf("Error locking account: %v", err)
+		}
+
+		if failures == cfg.Lockout.Threshold && userExists {
+			log.Printf("Account %s locked after %d failed login attempts", email, failures)
+			u.publishLockout(cfg, email, lockedUntil)
+		}
+	}
+
+	if ipAddress != "" {
+		ipKey := ipAttemptKey(ipAddress)
+		failures, err = u.loginAttemptRepo.RecordFailure(ctx, ipKey, cfg.Lockout.Window())
+		if err != nil {
+			log.Printf("Error recording login failure: %v", err)
+			return service_errors.InvalidCredentialsError
+		}
+
+		if cfg.Lockout.IPThreshold > 0 && failures >= cfg.Lockout.IPThreshold {
+			if err = u.loginAttemptRepo.LockUntil(ctx, ipKey, time.Now().Add(cfg.Lockout.Duration())); err != nil {
+				log.Printf("Error locking address: %v", err)
+			}
+			if failures == cfg.Lockout.IPThreshold {
+				log.Printf("Address %s locked after %d failed login attempts", ipAddress, failures)
+			}
+		}
+	}
+
+	return service_errors.InvalidCredentialsError
+}
+
+func (u *UserServiceImpl) resetLoginFailures(ctx context.Context, email string) {
+	if err := u.loginAttemptRepo.ResetLoginAttempts(ctx, accountAttemptKey(email)); err != nil {
+		log.Printf("Error resetting login attempts: %v", err)
+	}
+}
+
+// verifyAccountPassword re-checks the password of a signed-in user. Wrong
+// guesses count towards the same account lockout as Login, so a stolen access
+// token cannot be used to brute-force the password.
+func (u *UserServiceImpl) verifyAccountPassword(ctx context.Context, cfg *config.Config, user *entities.User, password string, hashedPWD []byte) error {
+	email := cfg.Email.Normalize(user.Email)
+	if err := u.checkLoginLock(ctx, email, ""); err != nil {
+		return err
+	}
+
+	if err := u.passwords.Verify(password, hashedPWD); err != nil {
+		if errors.Is(err, hashing.ErrInvalidPassword) {
+			return u.loginFailed(ctx, cfg, email, "", true)
+		}
+		log.Printf("Password verification error: %v", err)
+		return service_errors.InternalServerError
+	}
+	u.resetLoginFailures(ctx, email)
+
+	return nil
+}
+
+func (u *UserServiceImpl) publishLockout(cfg *config.Config, email string, lockedUntil time.Time) {
+	message := value_objects.EmailMessage{
+		Type:      accountLockedEvent,
+		Email:     email,
+		ExpiresAt: lockedUntil,
+	}
+	u.publishEmail(cfg.BrokerConstants.AccountLocked, message)
+}
+
+func loginAttemptKeys(email string, ipAddress string) []string {
+	keys := []string{accountAttemptKey(email)}
+	if ipAddress != "" {
+		keys = append(keys, ipAttemptKey(ipAddress))
+	}
+	return keys
+}
+
+func accountAttemptKey(email string) string {
+	return "account:" + email
+}
+
+func ipAttemptKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+	"github.com/google/uuid"
+)
+
+const loginCodeEvent = "login_code"
+
+var emailLoginPurposes = []string{
+	entities.VerificationPurposeLoginCode,
+	entities.VerificationPurposeMagicLink,
+}
+
+func (u *UserServiceImpl) RequestLoginCode(ctx context.Context, cfg *config.Config, email string, clientID string) error {
+	audience, err := audienceFor(cfg, clientID)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.userRepo.GetUserByEmail(ctx, cfg.Email.Normalize(email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		log.Printf("Error getting user: %v", err)
+		return service_errors.InternalServerError
+	}
+	if checkLoginAllowed(user) != nil {
+		return nil
+	}
+
+	issuedAt, err := u.verificationRepo.LastIssuedAt(ctx, user.ID, entities.VerificationPurposeLoginCode)
+	if err != nil {
+		log.Printf("Error getting last login code: %v", err)
+		return service_errors.InternalServerError
+	}
+	if time.Since(issuedAt) < cfg.Email.ResendCooldown() {
+		log.Printf("Login code for user %s requested too often, skipping", user.ID)
+		return nil
+	}
+
+	if err = u.invalidateEmailLogins(ctx, user.ID); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(cfg.Email.LoginCodeTTL())
+
+	code, err := hashing.GenerateLoginCode()
+	if err != nil {
+		log.Printf("Error generating login code: %v", err)
+		return service_errors.InternalServerError
+	}
+	codeToken := &entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Purpose:   entities.VerificationPurposeLoginCode,
+		ExpiresAt: expiresAt,
+	}
+	codeToken.TokenHash = hashing.HashLoginCode(codeToken.ID, code)
+
+	linkToken := &entities.VerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Purpose:   entities.VerificationPurposeMagicLink,
+		ExpiresAt: expiresAt,
+	}
+	link, err := hashing.CreateMagicLink(hashing.TokenOptions{
+		UserID:   user.ID,
+		Version:  user.TokenVersion,
+		Issuer:   cfg.JWT.Issuer,
+		Audience: audience,
+	}, linkToken.ID, cfg.Email.LoginCodeTTL(), u.keys.SigningKey())
+	if err != nil {
+		log.Printf("Magic link generation error: %v", err)
+		return service_errors.InternalServerError
+	}
+	linkToken.TokenHash = hashing.HashVerificationToken(link)
+
+	for _, token := range []*entities.VerificationToken{codeToken, linkToken} {
+		if err = u.verificationRepo.InsertVerificationToken(ctx, token); err != nil {
+			log.Printf("Error storing %s token: %v", token.Purpose, err)
+			return service_errors.InternalServerError
+		}
+	}
+
+	message := value_objects.EmailMessage{
+		Type:      loginCodeEvent,
+		Email:     user.Email,
+		Token:     link,
+		Code:      code,
+		ExpiresAt: expiresAt,
+	}
+	u.publishEmail(cfg.BrokerConstants.LoginCode, message)
+
+	return nil
+}
+
+func (u *UserServiceImpl) LoginWithCode(ctx context.Context, cfg *config.Config, email string, code string, client value_objects.ClientInfo) (value_objects.AuthResponse, error) {
+	email = cfg.Email.Normalize(email)
+
+	if err := u.checkLoginLock(ctx, email, client.IPAddress); err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	user, err := u.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			_ = u.loginFailed(ctx, cfg, email, client.IPAddress, false)
+			return value_objects.AuthResponse{}, service_errors.InvalidLoginCodeError
+		}
+		log.Printf("Error getting user: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	pending, err := u.verificationRepo.GetLatestActiveToken(ctx, user.ID, entities.VerificationPurposeLoginCode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			_ = u.loginFailed(ctx, cfg, email, client.IPAddress, true)
+			return value_objects.AuthResponse{}, service_errors.InvalidLoginCodeError
+		}
+		log.Printf("Error getting login code: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	if subtle.ConstantTimeCompare(hashing.HashLoginCode(pending.ID, code), pending.TokenHash) != 1 {
+		u.loginCodeFailed(ctx, cfg, pending, email, client.IPAddress)
+		return value_objects.AuthResponse{}, service_errors.InvalidLoginCodeError
+	}
+
+	if _, err = u.verificationRepo.ConsumeVerificationToken(ctx, entities.VerificationPurposeLoginCode, pending.TokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return value_objects.AuthResponse{}, service_errors.InvalidLoginCodeError
+		}
+		log.Printf("Error consuming login code: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	return u.completeEmailLogin(ctx, cfg, user, client)
+}
+
+func (u *UserServiceImpl) LoginWithMagicLink(ctx context.Context, cfg *config.Config, token string, client value_objects.ClientInfo) (value_objects.AuthResponse, error) {
+	claims, err := hashing.ParseAndValidate(token, u.keys, validationOptions(cfg, hashing.MagicLinkTokenType))
+	if err != nil {
+		return value_objects.AuthResponse{}, tokenError(err)
+	}
+
+	if _, err = u.verificationRepo.ConsumeVerificationToken(ctx, entities.VerificationPurposeMagicLink, hashing.HashVerificationToken(token)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return value_objects.AuthResponse{}, service_errors.InvalidTokenError
+		}
+		log.Printf("Error consuming magic link: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	user, err := u.userRepo.GetUserByID(ctx, claims.UserID())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return value_objects.AuthResponse{}, service_errors.InvalidTokenError
+		}
+		log.Printf("Error getting user: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+	if claims.Version != user.TokenVersion {
+		return value_objects.AuthResponse{}, service_errors.TokenRevokedError
+	}
+
+	if len(claims.Audience) > 0 {
+		client.ClientID = claims.Audience[0]
+	}
+	return u.completeEmailLogin(ctx, cfg, user, client)
+}
+
+func (u *UserServiceImpl) completeEmailLogin(ctx context.Context, cfg *config.Config, user *entities.User, client value_objects.ClientInfo) (value_objects.AuthResponse, error) {
+	if err := u.invalidateEmailLogins(ctx, user.ID); err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+	if err := checkLoginAllowed(user); err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	return u.completeLogin(ctx, cfg, user, client)
+}
+
+func (u *UserServiceImpl) invalidateEmailLogins(ctx context.Context, userID uuid.UUID) error {
+	for _, purpose := range emailLoginPurposes {
+		if err := u.verificationRepo.InvalidateUserTokens(ctx, userID, purpose); err != nil {
+			log.Printf("Error invalidating %s tokens: %v", purpose, err)
+			return service_errors.InternalServerError
+		}
+	}
+	return nil
+}
+
+// loginCodeFailed counts a wrong code against the account and address like a
+// failed password, so requesting a new code does not reset the limit, and
+// revokes the pending code after LOGIN_CODE_MAX_ATTEMPTS.
+func (u *UserServiceImpl) loginCodeFailed(
+	ctx context.Context,
+	cfg *config.Config,
+	pending *entities.VerificationToken,
+	email string,
+	ipAddress string,
+) {
+	_ = u.loginFailed(ctx, cfg, email, ipAddress, true)
+
+	failures, err := u.loginAttemptRepo.RecordFailure(ctx, loginCodeAttemptKey(pending.ID), time.Until(pending.ExpiresAt))
+	if err != nil {
+		log.Printf("Error recording login code failure: %v", err)
+		return
+	}
+	if cfg.Email.LoginCodeMaxAttempts <= 0 || failures < cfg.Email.LoginCodeMaxAttempts {
+		return
+	}
+
+	log.Printf("Login code for user %s revoked after %d failed attempts", pending.UserID, failures)
+	if err = u.verificationRepo.InvalidateUserTokens(ctx, pending.UserID, entities.VerificationPurposeLoginCode); err != nil {
+		log.Printf("Error invalidating login codes: %v", err)
+	}
+}
+
+func loginCodeAttemptKey(tokenID uuid.UUID) string {
+	return "login_code:" + tokenID.String()
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+	"github.com/google/uuid"
+)
+
+func (s *testService) issueLoginCode(user *entities.User, code string) {
+	id := uuid.New()
+	s.tokens.tokens = append(s.tokens.tokens, &entities.VerificationToken{
+		ID:        id,
+		UserID:    user.ID,
+		Purpose:   entities.VerificationPurposeLoginCode,
+		TokenHash: hashing.HashLoginCode(id, code),
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	})
+}
+
+func TestLoginWithCodeLocksAccountAcrossCodes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	s.cfg.Email.LoginCodeMaxAttempts = 10
+	user := s.addUser("user@example.com")
+	client := value_objects.ClientInfo{IPAddress: "192.0.2.1"}
+
+	// Each guess targets a freshly requested code, so the per-code limit
+	// never triggers and only the account lockout can stop the attacker.
+	for attempt := 1; attempt <= s.cfg.Lockout.Threshold; attempt++ {
+		s.issueLoginCode(user, "123456")
+		if _, err := s.LoginWithCode(ctx, s.cfg, user.Email, "654321", client); !errors.Is(err, service_errors.InvalidLoginCodeError) {
+			t.Fatalf("attempt %d: LoginWithCode error = %v, want %v", attempt, err, service_errors.InvalidLoginCodeError)
+		}
+	}
+
+	s.issueLoginCode(user, "123456")
+	if _, err := s.LoginWithCode(ctx, s.cfg, user.Email, "123456", client); !errors.Is(err, service_errors.AccountLockedError) {
+		t.Fatalf("LoginWithCode with a valid code on a locked account: error = %v, want %v", err, service_errors.AccountLockedError)
+	}
+}
+
+func TestLoginWithCodeCountsUnknownAccountsAndMissingCodes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{name: "unknown account", email: "nobody@example.com"},
+		{name: "no pending code", email: user.Email},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.LoginWithCode(ctx, s.cfg, tt.email, "123456", value_objects.ClientInfo{}); !errors.Is(err, service_errors.InvalidLoginCodeError) {
+				t.Fatalf("LoginWithCode error = %v, want %v", err, service_errors.InvalidLoginCodeError)
+			}
+			if failures := s.attempts.failures(accountAttemptKey(tt.email)); failures != 1 {
+				t.Errorf("account failures = %d, want 1", failures)
+			}
+		})
+	}
+}
+
+func TestLoginWithCodeSuccessResetsFailures(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+
+	s.issueLoginCode(user, "123456")
+	if _, err := s.LoginWithCode(ctx, s.cfg, user.Email, "654321", value_objects.ClientInfo{}); !errors.Is(err, service_errors.InvalidLoginCodeError) {
+		t.Fatalf("LoginWithCode error = %v, want %v", err, service_errors.InvalidLoginCodeError)
+	}
+
+	tokens, err := s.LoginWithCode(ctx, s.cfg, user.Email, "123456", value_objects.ClientInfo{})
+	if err != nil {
+		t.Fatalf("LoginWithCode: %v", err)
+	}
+	if tokens.AccessToken == "" {
+		t.Error("LoginWithCode returned no access token")
+	}
+	if failures := s.attempts.failures(accountAttemptKey(user.Email)); failures != 0 {
+		t.Errorf("account failures after success = %d, want 0", failures)
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+	"authService/internal/utils/totp"
+	"github.com/google/uuid"
+)
+
+func (u *UserServiceImpl) EnrollTOTP(ctx context.Context, cfg *config.Config, accessToken string) (value_objects.TOTPEnrollment, error) {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return value_objects.TOTPEnrollment{}, err
+	}
+	if u.secretBox == nil {
+		return value_objects.TOTPEnrollment{}, service_errors.MFAUnavailableError
+	}
+
+	user, err := u.activeUser(ctx, claims.UserID())
+	if err != nil {
+		return value_objects.TOTPEnrollment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Error generating totp secret: %v", err)
+		return value_objects.TOTPEnrollment{}, service_errors.InternalServerError
+	}
+
+	encrypted, err := u.secretBox.Seal(secret)
+	if err != nil {
+		log.Printf("Error encrypting totp secret: %v", err)
+		return value_objects.TOTPEnrollment{}, service_errors.InternalServerError
+	}
+
+	saved, err := u.totpRepo.SavePendingTOTP(ctx, user.ID, encrypted)
+	if err != nil {
+		log.Printf("Error saving totp secret: %v", err)
+		return value_objects.TOTPEnrollment{}, service_errors.InternalServerError
+	}
+	if !saved {
+		return value_objects.TOTPEnrollment{}, service_errors.MFAAlreadyEnabledError
+	}
+
+	return value_objects.TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(cfg.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (u *UserServiceImpl) ConfirmTOTP(ctx context.Context, cfg *config.Config, accessToken string, code string) ([]string, error) {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := u.totpRepo.GetTOTP(ctx, claims.UserID())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service_errors.MFANotEnrolledError
+		}
+		log.Printf("Error getting totp credential: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+	if credential.ConfirmedAt != nil {
+		return nil, service_errors.MFAAlreadyEnabledError
+	}
+
+	step, err := u.validateTOTP(cfg, credential, code)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed, err := u.totpRepo.ConfirmTOTP(ctx, credential.UserID, step)
+	if err != nil {
+		log.Printf("Error confirming totp: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+	if !confirmed {
+		return nil, service_errors.MFAAlreadyEnabledError
+	}
+
+	log.Printf("TOTP enabled for user %s", credential.UserID)
+	return u.issueRecoveryCodes(ctx, cfg, credential.UserID)
+}
+
+func (u *UserServiceImpl) VerifyMFA(
+	ctx context.Context,
+	cfg *config.Config,
+	verification value_objects.MFAVerification,
+	client value_objects.ClientInfo,
+) (value_objects.AuthResponse, error) {
+	claims, err := hashing.ParseAndValidate(verification.Challenge, u.keys, validationOptions(cfg, hashing.MFAPendingTokenType))
+	if err != nil {
+		return value_objects.AuthResponse{}, tokenError(err)
+	}
+
+	denied, err := u.denylistRepo.IsTokenDenied(ctx, claims.TokenID())
+	if err != nil {
+		log.Printf("Error checking token denylist: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+	if denied {
+		return value_objects.AuthResponse{}, service_errors.InvalidTokenError
+	}
+
+	user, err := u.activeUser(ctx, claims.UserID())
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+	if claims.Version != user.TokenVersion {
+		return value_objects.AuthResponse{}, service_errors.TokenRevokedError
+	}
+
+	email := cfg.Email.Normalize(user.Email)
+	if err = u.checkLoginLock(ctx, email, client.IPAddress); err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	credential, err := u.confirmedTOTP(ctx, user.ID)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+	if credential == nil {
+		return value_objects.AuthResponse{}, service_errors.InvalidTokenError
+	}
+
+	if verification.RecoveryCode != "" {
+		err = u.useRecoveryCode(ctx, user.ID, verification.RecoveryCode)
+	} else {
+		err = u.useTOTPCode(ctx, cfg, credential, verification.Code)
+	}
+	if err != nil {
+		if errors.Is(err, service_errors.InvalidMFACodeError) {
+			u.mfaFailed(ctx, cfg, claims, email, client.IPAddress)
+		}
+		return value_objects.AuthResponse{}, err
+	}
+
+	if err = u.denylistRepo.DenyToken(ctx, claims.TokenID(), claims.ExpiresAt.Time); err != nil {
+		log.Printf("Error denying mfa challenge: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+	if err = u.loginAttemptRepo.ResetLoginAttempts(ctx, mfaAttemptKey(claims.TokenID())); err != nil {
+		log.Printf("Error resetting mfa attempts: %v", err)
+	}
+	u.resetLoginFailures(ctx, email)
+
+	if len(claims.Audience) > 0 {
+		client.ClientID = claims.Audience[0]
+	}
+	return u.startSession(ctx, cfg, user, client)
+}
+
+func (u *UserServiceImpl) startMFAChallenge(cfg *config.Config, user *entities.User, client value_objects.ClientInfo) (value_objects.AuthResponse, error) {
+	audience, err := audienceFor(cfg, client.ClientID)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	challenge, err := hashing.CreateMFAChallenge(hashing.TokenOptions{
+		UserID:   user.ID,
+		Version:  user.TokenVersion,
+		Issuer:   cfg.JWT.Issuer,
+		Audience: audience,
+	}, cfg.MFA.ChallengeTTL(), u.keys.SigningKey())
+	if err != nil {
+		log.Printf("MFA challenge generation error: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	return value_objects.AuthResponse{
+		MFARequired:  true,
+		MFAChallenge: challenge,
+	}, nil
+}
+
+func (u *UserServiceImpl) confirmedTOTP(ctx context.Context, userID uuid.UUID) (*entities.TOTPCredential, error) {
+	credential, err := u.totpRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Error getting totp credential: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+	if credential.ConfirmedAt == nil {
+		return nil, nil
+	}
+
+	return credential, nil
+}
+
+func (u *UserServiceImpl) validateTOTP(cfg *config.Config, credential *entities.TOTPCredential, code string) (int64, error) {
+	if u.secretBox == nil {
+		log.Printf("TOTP credential for user %s cannot be used: MFA_ENCRYPTION_KEY is not set", credential.UserID)
+		return 0, service_errors.InternalServerError
+	}
+
+	secret, err := u.secretBox.Open(credential.Secret)
+	if err != nil {
+		log.Printf("Error decrypting totp secret for user %s: %v", credential.UserID, err)
+		return 0, service_errors.InternalServerError
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), cfg.MFA.TOTPSkew)
+	if !ok {
+		return 0, service_errors.InvalidMFACodeError
+	}
+
+	return step, nil
+}
+
+func (u *UserServiceImpl) useTOTPCode(ctx context.Context, cfg *config.Config, credential *entities.TOTPCredential, code string) error {
+	step, err := u.validateTOTP(cfg, credential, code)
+	if err != nil {
+		return err
+	}
+
+	used, err := u.totpRepo.UseTOTPStep(ctx, credential.UserID, step)
+	if err != nil {
+		log.Printf("Error storing used totp step: %v", err)
+		return service_errors.InternalServerError
+	}
+	if !used {
+		return service_errors.InvalidMFACodeError
+	}
+
+	return nil
+}
+
+// mfaFailed counts a wrong code against the account and address like a failed
+// password, so fresh challenges from new logins do not reset the limit, and
+// revokes the challenge itself after MFA_MAX_ATTEMPTS.
+func (u *UserServiceImpl) mfaFailed(ctx context.Context, cfg *config.Config, claims *hashing.Claims, email string, ipAddress string) {
+	_ = u.loginFailed(ctx, cfg, email, ipAddress, true)
+
+	failures, err := u.loginAttemptRepo.RecordFailure(ctx, mfaAttemptKey(claims.TokenID()), cfg.MFA.ChallengeTTL())
+	if err != nil {
+		log.Printf("Error recording mfa failure: %v", err)
+		return
+	}
+	if cfg.MFA.MaxAttempts <= 0 || failures < cfg.MFA.MaxAttempts {
+		return
+	}
+
+	log.Printf("MFA challenge for user %s revoked after %d failed attempts", claims.UserID(), failures)
+	if err = u.denylistRepo.DenyToken(ctx, claims.TokenID(), claims.ExpiresAt.Time); err != nil {
+		log.Printf("Error denying mfa challenge: %v", err)
+	}
+}
+
+func mfaAttemptKey(challengeID uuid.UUID) string {
+	return "mfa:" + challengeID.String()
+}
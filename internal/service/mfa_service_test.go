@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/service_errors"
+	"authService/internal/utils/totp"
+)
+
+func (s *testService) enableTOTP(t *testing.T, user *entities.User) []byte {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	sealed, err := s.secretBox.Seal(secret)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	confirmedAt := time.Now()
+	s.totp.credentials[user.ID] = &entities.TOTPCredential{
+		UserID:      user.ID,
+		Secret:      sealed,
+		ConfirmedAt: &confirmedAt,
+	}
+	return secret
+}
+
+func (s *testService) mfaChallenge(t *testing.T, user *entities.User) string {
+	t.Helper()
+
+	response, err := s.completeLogin(context.Background(), s.cfg, user, value_objects.ClientInfo{})
+	if err != nil {
+		t.Fatalf("completeLogin: %v", err)
+	}
+	if !response.MFARequired {
+		t.Fatal("completeLogin did not require MFA")
+	}
+	return response.MFAChallenge
+}
+
+func wrongCode(secret []byte) string {
+	code := []byte(totp.Code(secret, totp.Step(time.Now())))
+	for i := range code {
+		code[i] = '0' + (code[i]-'0'+5)%10
+	}
+	return string(code)
+}
+
+func TestVerifyMFALocksAccountAcrossChallenges(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+	secret := s.enableTOTP(t, user)
+	client := value_objects.ClientInfo{IPAddress: "192.0.2.1"}
+
+	// Every wrong code comes with a fresh challenge, as if the attacker
+	// logged in again with the known password before each guess.
+	for attempt := 1; attempt <= s.cfg.Lockout.Threshold; attempt++ {
+		verification := value_objects.MFAVerification{Challenge: s.mfaChallenge(t, user), Code: wrongCode(secret)}
+		if _, err := s.VerifyMFA(ctx, s.cfg, verification, client); !errors.Is(err, service_errors.InvalidMFACodeError) {
+			t.Fatalf("attempt %d: VerifyMFA error = %v, want %v", attempt, err, service_errors.InvalidMFACodeError)
+		}
+	}
+
+	if failures := s.attempts.failures(accountAttemptKey(user.Email)); failures != s.cfg.Lockout.Threshold {
+		t.Errorf("account failures = %d, want %d", failures, s.cfg.Lockout.Threshold)
+	}
+
+	verification := value_objects.MFAVerification{
+		Challenge: s.mfaChallenge(t, user),
+		Code:      totp.Code(secret, totp.Step(time.Now())),
+	}
+	if _, err := s.VerifyMFA(ctx, s.cfg, verification, client); !errors.Is(err, service_errors.AccountLockedError) {
+		t.Fatalf("VerifyMFA with a valid code on a locked account: error = %v, want %v", err, service_errors.AccountLockedError)
+	}
+}
+
+func TestVerifyMFASuccessResetsFailures(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+	secret := s.enableTOTP(t, user)
+
+	verification := value_objects.MFAVerification{Challenge: s.mfaChallenge(t, user), Code: wrongCode(secret)}
+	if _, err := s.VerifyMFA(ctx, s.cfg, verification, value_objects.ClientInfo{}); !errors.Is(err, service_errors.InvalidMFACodeError) {
+		t.Fatalf("VerifyMFA error = %v, want %v", err, service_errors.InvalidMFACodeError)
+	}
+
+	// A fresh challenge alone must not clear the counter.
+	challenge := s.mfaChallenge(t, user)
+	if failures := s.attempts.failures(accountAttemptKey(user.Email)); failures != 1 {
+		t.Fatalf("account failures after new challenge = %d, want 1", failures)
+	}
+
+	verification = value_objects.MFAVerification{Challenge: challenge, Code: totp.Code(secret, totp.Step(time.Now()))}
+	tokens, err := s.VerifyMFA(ctx, s.cfg, verification, value_objects.ClientInfo{})
+	if err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+	if tokens.AccessToken == "" {
+		t.Error("VerifyMFA returned no access token")
+	}
+	if failures := s.attempts.failures(accountAttemptKey(user.Email)); failures != 0 {
+		t.Errorf("account failures after success = %d, want 0", failures)
+	}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+	"slices"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+)
+
+var defaultGrantTypes = []string{
+	entities.GrantTypeAuthorizationCode,
+	entities.GrantTypeRefreshToken,
+}
+
+func (u *UserServiceImpl) RegisterOAuthClient(
+	ctx context.Context,
+	cfg *config.Config,
+	registration value_objects.OAuthClientRegistration,
+) (value_objects.OAuthClientCredentials, error) {
+	if _, err := audienceFor(cfg, registration.ClientID); err != nil {
+		return value_objects.OAuthClientCredentials{}, err
+	}
+	// Tokens of the client would otherwise pass as login page sessions.
+	if registration.ClientID == cfg.OAuth.LoginClientID {
+		return value_objects.OAuthClientCredentials{}, service_errors.InvalidOAuthClientError
+	}
+	for _, redirectURI := range registration.RedirectURIs {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			return value_objects.OAuthClientCredentials{}, service_errors.InvalidRedirectURIError
+		}
+	}
+
+	client := &entities.OAuthClient{
+		ID:           registration.ClientID,
+		Name:         registration.Name,
+		RedirectURIs: registration.RedirectURIs,
+		GrantTypes:   registration.GrantTypes,
+		Public:       registration.Public,
+		FirstParty:   registration.FirstParty,
+	}
+	if len(client.GrantTypes) == 0 {
+		client.GrantTypes = defaultGrantTypes
+	}
+
+	credentials := value_objects.OAuthClientCredentials{ClientID: client.ID}
+	if !client.Public {
+		secret, secretHash, err := hashing.GenerateVerificationToken()
+		if err != nil {
+			log.Printf("Error generating client secret: %v", err)
+			return value_objects.OAuthClientCredentials{}, service_errors.InternalServerError
+		}
+		client.SecretHash = secretHash
+		credentials.ClientSecret = secret
+	}
+
+	if err := u.oauthClientRepo.InsertClient(ctx, client); err != nil {
+		if errors.Is(err, service_errors.OAuthClientAlreadyExistsError) {
+			return value_objects.OAuthClientCredentials{}, err
+		}
+		log.Printf("Error inserting oauth client: %v", err)
+		return value_objects.OAuthClientCredentials{}, service_errors.InternalServerError
+	}
+
+	log.Printf("OAuth client registered: %s", client.ID)
+	return credentials, nil
+}
+
+func (u *UserServiceImpl) ValidateAuthorizationRequest(ctx context.Context, request value_objects.AuthorizationRequest) error {
+	_, err := u.authorizationClient(ctx, request)
+	return err
+}
+
+// ValidateOAuthSession checks a login page token before it is stored in the
+// /authorize session cookie and returns the token expiry.
+func (u *UserServiceImpl) ValidateOAuthSession(ctx context.Context, cfg *config.Config, sessionToken string) (time.Time, error) {
+	claims, err := u.authenticateLoginSession(ctx, cfg, sessionToken)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return claims.ExpiresAt.Time, nil
+}
+
+func (u *UserServiceImpl) Authorize(
+	ctx context.Context,
+	cfg *config.Config,
+	sessionToken string,
+	request value_objects.AuthorizationRequest,
+) (string, error) {
+	client, err := u.authorizationClient(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := u.authenticateLoginSession(ctx, cfg, sessionToken)
+	if err != nil {
+		return "", err
+	}
+
+	if !client.FirstParty {
+		granted, err := u.oauthClientRepo.HasConsent(ctx, claims.UserID(), client.ID)
+		if err != nil {
+			log.Printf("Error checking oauth consent: %v", err)
+			return "", service_errors.InternalServerError
+		}
+		if !granted {
+			return "", service_errors.ConsentRequiredError
+		}
+	}
+
+	code, codeHash, err := hashing.GenerateVerificationToken()
+	if err != nil {
+		log.Printf("Error generating authorization code: %v", err)
+		return "", service_errors.InternalServerError
+	}
+
+	stored := &entities.AuthorizationCode{
+		CodeHash:      codeHash,
+		ClientID:      client.ID,
+		UserID:        claims.UserID(),
+		RedirectURI:   request.RedirectURI,
+		CodeChallenge: request.CodeChallenge,
+		ExpiresAt:     time.Now().Add(cfg.OAuth.CodeTTL()),
+	}
+	if err = u.authCodeRepo.InsertAuthorizationCode(ctx, stored); err != nil {
+		log.Printf("Error storing authorization code: %v", err)
+		return "", service_errors.InternalServerError
+	}
+
+	return code, nil
+}
+
+func (u *UserServiceImpl) GrantOAuthConsent(
+	ctx context.Context,
+	cfg *config.Config,
+	sessionToken string,
+	request value_objects.AuthorizationRequest,
+) error {
+	client, err := u.authorizationClient(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	claims, err := u.authenticateLoginSession(ctx, cfg, sessionToken)
+	if err != nil {
+		return err
+	}
+
+	if err = u.oauthClientRepo.InsertConsent(ctx, claims.UserID(), client.ID); err != nil {
+		log.Printf("Error storing oauth consent: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	log.Printf("OAuth consent granted: user %s, client %s", claims.UserID(), client.ID)
+	return nil
+}
+
+func (u *UserServiceImpl) ExchangeAuthorizationCode(
+	ctx context.Context,
+	cfg *config.Config,
+	exchange value_objects.CodeExchange,
+	client value_objects.ClientInfo,
+) (value_objects.AuthResponse, error) {
+	oauthClient, err := u.authenticateOAuthClient(ctx, exchange.Client, entities.GrantTypeAuthorizationCode)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	codeHash := hashing.HashVerificationToken(exchange.Code)
+	stored, err := u.authCodeRepo.ConsumeAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			u.revokeReplayedCode(ctx, codeHash)
+			return value_objects.AuthResponse{}, service_errors.InvalidGrantError
+		}
+		log.Printf("Error consuming authorization code: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	if stored.ClientID != oauthClient.ID || stored.RedirectURI != exchange.RedirectURI {
+		return value_objects.AuthResponse{}, service_errors.InvalidGrantError
+	}
+	if !hashing.VerifyPKCE(exchange.CodeVerifier, stored.CodeChallenge) {
+		return value_objects.AuthResponse{}, service_errors.InvalidGrantError
+	}
+
+	user, err := u.activeUser(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, service_errors.UserNotFoundError) {
+			return value_objects.AuthResponse{}, service_errors.InvalidGrantError
+		}
+		return value_objects.AuthResponse{}, err
+	}
+
+	client.ClientID = oauthClient.ID
+	tokens, err := u.startSession(ctx, cfg, user, client)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	if err = u.authCodeRepo.SetAuthorizationCodeSession(ctx, codeHash, tokens.SessionID); err != nil {
+		log.Printf("Error linking authorization code to session: %v", err)
+	}
+
+	return tokens, nil
+}
+
+func (u *UserServiceImpl) RefreshOAuthToken(
+	ctx context.Context,
+	cfg *config.Config,
+	credentials value_objects.OAuthClientCredentials,
+	refreshToken string,
+) (value_objects.AuthResponse, error) {
+	oauthClient, err := u.authenticateOAuthClient(ctx, credentials, entities.GrantTypeRefreshToken)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	claims, err := hashing.ParseAndValidate(refreshToken, u.keys, validationOptions(cfg, hashing.RefreshTokenType))
+	if err != nil || !slices.Contains(claims.Audience, oauthClient.ID) {
+		return value_objects.AuthResponse{}, service_errors.InvalidGrantError
+	}
+
+	tokens, err := u.rotateRefreshToken(ctx, cfg, claims)
+	if err != nil {
+		if errors.Is(err, service_errors.InternalServerError) {
+			return value_objects.AuthResponse{}, err
+		}
+		return value_objects.AuthResponse{}, service_errors.InvalidGrantError
+	}
+
+	return tokens, nil
+}
+
+func (u *UserServiceImpl) authorizationClient(ctx context.Context, request value_objects.AuthorizationRequest) (*entities.OAuthClient, error) {
+	client, err := u.getOAuthClient(ctx, request.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(client.RedirectURIs, request.RedirectURI) {
+		return nil, service_errors.InvalidRedirectURIError
+	}
+	if !slices.Contains(client.GrantTypes, entities.GrantTypeAuthorizationCode) {
+		return nil, service_errors.UnauthorizedClientError
+	}
+
+	return client, nil
+}
+
+// authenticateLoginSession accepts only access tokens issued to the login page.
+// OAuth clients share JWT_AUDIENCES, so their tokens must not be able to
+// authorize codes for other clients.
+func (u *UserServiceImpl) authenticateLoginSession(ctx context.Context, cfg *config.Config, sessionToken string) (*hashing.Claims, error) {
+	if cfg.OAuth.LoginClientID == "" {
+		return nil, service_errors.OAuthLoginUnavailableError
+	}
+
+	claims, err := u.authenticate(ctx, cfg, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims.Audience) != 1 || claims.Audience[0] != cfg.OAuth.LoginClientID {
+		return nil, service_errors.InvalidTokenError
+	}
+
+	return claims, nil
+}
+
+func (u *UserServiceImpl) authenticateOAuthClient(
+	ctx context.Context,
+	credentials value_objects.OAuthClientCredentials,
+	grantType string,
+) (*entities.OAuthClient, error) {
+	client, err := u.getOAuthClient(ctx, credentials.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public {
+		if credentials.ClientSecret != "" {
+			return nil, service_errors.InvalidOAuthClientError
+		}
+	} else {
+		secretHash := hashing.HashVerificationToken(credentials.ClientSecret)
+		if credentials.ClientSecret == "" || subtle.ConstantTimeCompare(secretHash, client.SecretHash) != 1 {
+			return nil, service_errors.InvalidOAuthClientError
+		}
+	}
+
+	if !slices.Contains(client.GrantTypes, grantType) {
+		return nil, service_errors.UnauthorizedClientError
+	}
+
+	return client, nil
+}
+
+func (u *UserServiceImpl) getOAuthClient(ctx context.Context, clientID string) (*entities.OAuthClient, error) {
+	if clientID == "" {
+		return nil, service_errors.InvalidOAuthClientError
+	}
+
+	client, err := u.oauthClientRepo.GetClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service_errors.InvalidOAuthClientError
+		}
+		log.Printf("Error getting oauth client: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	return client, nil
+}
+
+// revokeReplayedCode ends the session issued for an authorization code that
+// is presented a second time, as the code has most likely leaked.
+func (u *UserServiceImpl) revokeReplayedCode(ctx context.Context, codeHash []byte) {
+	stored, err := u.authCodeRepo.GetAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error getting authorization code: %v", err)
+		}
+		return
+	}
+	if stored.UsedAt == nil || stored.SessionID == nil {
+		return
+	}
+
+	log.Printf("Authorization code replay for client %s, revoking session %s", stored.ClientID, *stored.SessionID)
+	if err = u.endSession(ctx, *stored.SessionID); err != nil {
+		log.Printf("Error revoking session after code replay: %v", err)
+	}
+}
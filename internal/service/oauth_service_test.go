@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+)
+
+const (
+	testLoginClientID = "login"
+	testRedirectURI   = "https://app.test/callback"
+)
+
+func newOAuthTestService(t *testing.T) *testService {
+	t.Helper()
+
+	s := newTestService(t)
+	s.cfg.JWT.Audiences = append(s.cfg.JWT.Audiences, testLoginClientID)
+	s.cfg.OAuth.LoginClientID = testLoginClientID
+	s.cfg.OAuth.CodeTTLSeconds = 60
+	return s
+}
+
+func (s *testService) addOAuthClient(id string, firstParty bool) value_objects.AuthorizationRequest {
+	s.oauth.clients[id] = &entities.OAuthClient{
+		ID:           id,
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   []string{entities.GrantTypeAuthorizationCode},
+		Public:       true,
+		FirstParty:   firstParty,
+	}
+	return value_objects.AuthorizationRequest{
+		ClientID:      id,
+		RedirectURI:   testRedirectURI,
+		CodeChallenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+	}
+}
+
+func (s *testService) loginSession(t *testing.T, user *entities.User) string {
+	t.Helper()
+
+	tokens, err := s.startSession(context.Background(), s.cfg, user, value_objects.ClientInfo{ClientID: testLoginClientID})
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	return tokens.AccessToken
+}
+
+func TestAuthorizeRejectsOAuthClientTokens(t *testing.T) {
+	ctx := context.Background()
+	s := newOAuthTestService(t)
+	user := s.addUser("user@example.com")
+	request := s.addOAuthClient("web-app", true)
+
+	// accessToken is issued for "web-app", as /token would issue it.
+	_, err := s.Authorize(ctx, s.cfg, s.accessToken(t, user), request)
+	if !errors.Is(err, service_errors.InvalidTokenError) {
+		t.Fatalf("Authorize with a client token: error = %v, want %v", err, service_errors.InvalidTokenError)
+	}
+	if len(s.codes.codes) != 0 {
+		t.Fatal("authorization code was issued for a client token")
+	}
+
+	s.cfg.OAuth.LoginClientID = ""
+	_, err = s.Authorize(ctx, s.cfg, s.loginSession(t, user), request)
+	if !errors.Is(err, service_errors.OAuthLoginUnavailableError) {
+		t.Fatalf("Authorize without OAUTH_LOGIN_CLIENT_ID: error = %v, want %v", err, service_errors.OAuthLoginUnavailableError)
+	}
+}
+
+func TestAuthorizeRequiresConsentForThirdPartyClient(t *testing.T) {
+	ctx := context.Background()
+	s := newOAuthTestService(t)
+	user := s.addUser("user@example.com")
+	request := s.addOAuthClient("web-app", false)
+	session := s.loginSession(t, user)
+
+	if _, err := s.Authorize(ctx, s.cfg, session, request); !errors.Is(err, service_errors.ConsentRequiredError) {
+		t.Fatalf("Authorize before consent: error = %v, want %v", err, service_errors.ConsentRequiredError)
+	}
+
+	if err := s.GrantOAuthConsent(ctx, s.cfg, s.accessToken(t, user), request); !errors.Is(err, service_errors.InvalidTokenError) {
+		t.Fatalf("GrantOAuthConsent with a client token: error = %v, want %v", err, service_errors.InvalidTokenError)
+	}
+	if err := s.GrantOAuthConsent(ctx, s.cfg, session, request); err != nil {
+		t.Fatalf("GrantOAuthConsent: %v", err)
+	}
+
+	code, err := s.Authorize(ctx, s.cfg, session, request)
+	if err != nil {
+		t.Fatalf("Authorize after consent: %v", err)
+	}
+	if code == "" || len(s.codes.codes) != 1 {
+		t.Fatal("Authorize after consent issued no code")
+	}
+	if stored := s.codes.codes[0]; stored.UserID != user.ID || stored.ClientID != request.ClientID {
+		t.Errorf("code issued to user %s for client %s, want %s and %s", stored.UserID, stored.ClientID, user.ID, request.ClientID)
+	}
+}
+
+func TestAuthorizeFirstPartyClientSkipsConsent(t *testing.T) {
+	s := newOAuthTestService(t)
+	user := s.addUser("user@example.com")
+	request := s.addOAuthClient("web-app", true)
+
+	if _, err := s.Authorize(context.Background(), s.cfg, s.loginSession(t, user), request); err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if len(s.codes.codes) != 1 {
+		t.Fatalf("stored codes = %d, want 1", len(s.codes.codes))
+	}
+}
+
+func TestRegisterOAuthClientRejectsLoginClientID(t *testing.T) {
+	s := newOAuthTestService(t)
+
+	registration := value_objects.OAuthClientRegistration{
+		ClientID:     testLoginClientID,
+		RedirectURIs: []string{testRedirectURI},
+		Public:       true,
+	}
+	_, err := s.RegisterOAuthClient(context.Background(), s.cfg, registration)
+	if !errors.Is(err, service_errors.InvalidOAuthClientError) {
+		t.Fatalf("RegisterOAuthClient(%q): error = %v, want %v", testLoginClientID, err, service_errors.InvalidOAuthClientError)
+	}
+	if len(s.oauth.clients) != 0 {
+		t.Fatal("login client was registered")
+	}
+}
+
+func TestRefreshRejectsOAuthClientTokens(t *testing.T) {
+	ctx := context.Background()
+	s := newOAuthTestService(t)
+	user := s.addUser("user@example.com")
+	s.addOAuthClient("web-app", true)
+	client := s.oauth.clients["web-app"]
+	client.GrantTypes = defaultGrantTypes
+	client.Public = false
+	client.SecretHash = hashing.HashVerificationToken("client-secret")
+
+	issued, err := s.startSession(ctx, s.cfg, user, value_objects.ClientInfo{ClientID: "web-app"})
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+
+	if _, err = s.Refresh(ctx, s.cfg, issued.RefreshToken); !errors.Is(err, service_errors.InvalidTokenError) {
+		t.Fatalf("Refresh of an OAuth client token: error = %v, want %v", err, service_errors.InvalidTokenError)
+	}
+
+	withoutSecret := value_objects.OAuthClientCredentials{ClientID: "web-app"}
+	if _, err = s.RefreshOAuthToken(ctx, s.cfg, withoutSecret, issued.RefreshToken); !errors.Is(err, service_errors.InvalidOAuthClientError) {
+		t.Fatalf("RefreshOAuthToken without the client secret: error = %v, want %v", err, service_errors.InvalidOAuthClientError)
+	}
+
+	credentials := value_objects.OAuthClientCredentials{ClientID: "web-app", ClientSecret: "client-secret"}
+	refreshed, err := s.RefreshOAuthToken(ctx, s.cfg, credentials, issued.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshOAuthToken: %v", err)
+	}
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == issued.RefreshToken {
+		t.Error("RefreshOAuthToken did not rotate the refresh token")
+	}
+
+	// Tokens of the login page are not bound to an OAuth client.
+	login, err := s.startSession(ctx, s.cfg, user, value_objects.ClientInfo{ClientID: testLoginClientID})
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	if _, err = s.Refresh(ctx, s.cfg, login.RefreshToken); err != nil {
+		t.Fatalf("Refresh of a login page token: %v", err)
+	}
+}
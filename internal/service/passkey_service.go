@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/service_errors"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+// passkeyUser adapts a user and their stored passkeys to webauthn.User. The
+// user handle is the account UUID, which is opaque and never reassigned.
+type passkeyUser struct {
+	user        *entities.User
+	credentials []webauthn.Credential
+}
+
+func (p *passkeyUser) WebAuthnID() []byte {
+	return p.user.ID[:]
+}
+
+func (p *passkeyUser) WebAuthnName() string {
+	return p.user.Email
+}
+
+func (p *passkeyUser) WebAuthnDisplayName() string {
+	return p.user.Email
+}
+
+func (p *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return p.credentials
+}
+
+func (u *UserServiceImpl) BeginPasskeyRegistration(ctx context.Context, cfg *config.Config, accessToken string) (value_objects.PasskeyCeremony, error) {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return value_objects.PasskeyCeremony{}, err
+	}
+	if u.relyingParty == nil {
+		return value_objects.PasskeyCeremony{}, service_errors.PasskeysUnavailableError
+	}
+
+	owner, err := u.loadPasskeyUser(ctx, claims.UserID())
+	if err != nil {
+		return value_objects.PasskeyCeremony{}, err
+	}
+
+	creation, session, err := u.relyingParty.BeginRegistration(
+		owner,
+		webauthn.WithExclusions(webauthn.Credentials(owner.credentials).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		log.Printf("Error starting passkey registration: %v", err)
+		return value_objects.PasskeyCeremony{}, service_errors.InternalServerError
+	}
+
+	return u.storeCeremony(ctx, cfg, &owner.user.ID, entities.WebAuthnPurposeRegistration, creation, session)
+}
+
+func (u *UserServiceImpl) FinishPasskeyRegistration(
+	ctx context.Context,
+	cfg *config.Config,
+	accessToken string,
+	challengeID uuid.UUID,
+	response []byte,
+) error {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return err
+	}
+	if u.relyingParty == nil {
+		return service_errors.PasskeysUnavailableError
+	}
+
+	session, err := u.consumeCeremony(ctx, challengeID, entities.WebAuthnPurposeRegistration, claims.UserID())
+	if err != nil {
+		return err
+	}
+
+	owner, err := u.loadPasskeyUser(ctx, claims.UserID())
+	if err != nil {
+		return err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		log.Printf("Invalid passkey registration response: %v", err)
+		return service_errors.InvalidPasskeyError
+	}
+
+	credential, err := u.relyingParty.CreateCredential(owner, *session, parsed)
+	if err != nil {
+		log.Printf("Passkey registration for user %s rejected: %v", owner.user.ID, err)
+		return service_errors.InvalidPasskeyError
+	}
+
+	if err = u.passkeyRepo.InsertPasskey(ctx, newPasskeyCredential(owner.user.ID, credential)); err != nil {
+		if errors.Is(err, service_errors.PasskeyAlreadyRegisteredError) {
+			return err
+		}
+		log.Printf("Error storing passkey: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	log.Printf("Passkey registered for user %s", owner.user.ID)
+	return nil
+}
+
+func (u *UserServiceImpl) BeginPasskeyLogin(ctx context.Context, cfg *config.Config) (value_objects.PasskeyCeremony, error) {
+	if u.relyingParty == nil {
+		return value_objects.PasskeyCeremony{}, service_errors.PasskeysUnavailableError
+	}
+
+	assertion, session, err := u.relyingParty.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		log.Printf("Error starting passkey login: %v", err)
+		return value_objects.PasskeyCeremony{}, service_errors.InternalServerError
+	}
+
+	return u.storeCeremony(ctx, cfg, nil, entities.WebAuthnPurposeLogin, assertion, session)
+}
+
+func (u *UserServiceImpl) FinishPasskeyLogin(
+	ctx context.Context,
+	cfg *config.Config,
+	challengeID uuid.UUID,
+	response []byte,
+	client value_objects.ClientInfo,
+) (value_objects.AuthResponse, error) {
+	if u.relyingParty == nil {
+		return value_objects.AuthResponse{}, service_errors.PasskeysUnavailableError
+	}
+
+	session, err := u.consumeCeremony(ctx, challengeID, entities.WebAuthnPurposeLogin, uuid.Nil)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		log.Printf("Invalid passkey login response: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InvalidPasskeyError
+	}
+
+	var owner *passkeyUser
+	credential, err := u.relyingParty.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		var lookupErr error
+		owner, lookupErr = u.passkeyOwner(ctx, rawID, userHandle)
+		return owner, lookupErr
+	}, *session, parsed)
+	if err != nil {
+		if errors.Is(err, service_errors.InternalServerError) {
+			return value_objects.AuthResponse{}, err
+		}
+		log.Printf("Passkey login rejected: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InvalidPasskeyError
+	}
+
+	if credential.Authenticator.CloneWarning {
+		log.Printf("Passkey sign count regression for user %s, credential disabled", owner.user.ID)
+		if err = u.passkeyRepo.FlagPasskeyClone(ctx, credential.ID); err != nil {
+			log.Printf("Error flagging cloned passkey: %v", err)
+		}
+		return value_objects.AuthResponse{}, service_errors.InvalidPasskeyError
+	}
+
+	if err = u.passkeyRepo.UpdatePasskeyUsage(ctx, credential.ID, credential.Authenticator.SignCount, credential.Flags.BackupState); err != nil {
+		log.Printf("Error updating passkey usage: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	if err = checkLoginAllowed(owner.user); err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	email := cfg.Email.Normalize(owner.user.Email)
+	if err = u.checkLoginLock(ctx, email, client.IPAddress); err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+	u.resetLoginFailures(ctx, email)
+
+	// Unlike completeLogin this skips TOTP: the ceremony requires user
+	// verification, so the authenticator has already checked a PIN or
+	// biometric on top of possession of the key.
+	return u.startSession(ctx, cfg, owner.user, client)
+}
+
+func (u *UserServiceImpl) passkeyOwner(ctx context.Context, rawID []byte, userHandle []byte) (*passkeyUser, error) {
+	stored, err := u.passkeyRepo.GetPasskey(ctx, rawID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service_errors.InvalidPasskeyError
+		}
+		log.Printf("Error getting passkey: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	userID, err := uuid.FromBytes(userHandle)
+	if err != nil || userID != stored.UserID {
+		return nil, service_errors.InvalidPasskeyError
+	}
+	if stored.CloneWarning {
+		return nil, service_errors.InvalidPasskeyError
+	}
+
+	owner, err := u.loadPasskeyUser(ctx, userID)
+	if errors.Is(err, service_errors.UserNotFoundError) {
+		return nil, service_errors.InvalidPasskeyError
+	}
+	return owner, err
+}
+
+func (u *UserServiceImpl) loadPasskeyUser(ctx context.Context, userID uuid.UUID) (*passkeyUser, error) {
+	user, err := u.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := u.passkeyRepo.ListUserPasskeys(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error listing passkeys: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	owner := &passkeyUser{
+		user:        user,
+		credentials: make([]webauthn.Credential, 0, len(stored)),
+	}
+	for _, credential := range stored {
+		owner.credentials = append(owner.credentials, webAuthnCredential(credential))
+	}
+
+	return owner, nil
+}
+
+func (u *UserServiceImpl) storeCeremony(
+	ctx context.Context,
+	cfg *config.Config,
+	userID *uuid.UUID,
+	purpose string,
+	options any,
+	session *webauthn.SessionData,
+) (value_objects.PasskeyCeremony, error) {
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		log.Printf("Error encoding passkey options: %v", err)
+		return value_objects.PasskeyCeremony{}, service_errors.InternalServerError
+	}
+
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		log.Printf("Error encoding passkey session: %v", err)
+		return value_objects.PasskeyCeremony{}, service_errors.InternalServerError
+	}
+
+	challenge := &entities.WebAuthnChallenge{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Purpose:     purpose,
+		SessionData: sessionJSON,
+		ExpiresAt:   time.Now().Add(cfg.WebAuthn.Timeout()),
+	}
+	if err = u.challengeRepo.InsertChallenge(ctx, challenge); err != nil {
+		log.Printf("Error storing passkey challenge: %v", err)
+		return value_objects.PasskeyCeremony{}, service_errors.InternalServerError
+	}
+
+	return value_objects.PasskeyCeremony{
+		ChallengeID: challenge.ID,
+		Options:     optionsJSON,
+	}, nil
+}
+
+func (u *UserServiceImpl) consumeCeremony(ctx context.Context, challengeID uuid.UUID, purpose string, userID uuid.UUID) (*webauthn.SessionData, error) {
+	challenge, err := u.challengeRepo.ConsumeChallenge(ctx, challengeID, purpose)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service_errors.InvalidTokenError
+		}
+		log.Printf("Error getting passkey challenge: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	if userID != uuid.Nil && (challenge.UserID == nil || *challenge.UserID != userID) {
+		return nil, service_errors.InvalidTokenError
+	}
+
+	var session webauthn.SessionData
+	if err = json.Unmarshal(challenge.SessionData, &session); err != nil {
+		log.Printf("Error decoding passkey session: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	return &session, nil
+}
+
+func newPasskeyCredential(userID uuid.UUID, credential *webauthn.Credential) *entities.PasskeyCredential {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return &entities.PasskeyCredential{
+		ID:              credential.ID,
+		UserID:          userID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+}
+
+func webAuthnCredential(credential entities.PasskeyCredential) webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, 0, len(credential.Transports))
+	for _, transport := range credential.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(transport))
+	}
+
+	return webauthn.Credential{
+		ID:              credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       credential.AAGUID,
+			SignCount:    credential.SignCount,
+			CloneWarning: credential.CloneWarning,
+		},
+	}
+}
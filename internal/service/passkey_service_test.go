@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/service_errors"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+const (
+	testRPID   = "auth.test"
+	testOrigin = "https://auth.test"
+
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// softwareAuthenticator is a minimal ES256 authenticator with "none"
+// attestation, enough to drive both passkey ceremonies end to end.
+type softwareAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	if _, err = rand.Read(credentialID); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+
+	return &softwareAuthenticator{key: key, credentialID: credentialID}
+}
+
+func (a *softwareAuthenticator) register(t *testing.T, options []byte, origin string) []byte {
+	t.Helper()
+
+	var creation protocol.CredentialCreation
+	if err := json.Unmarshal(options, &creation); err != nil {
+		t.Fatalf("decode creation options: %v", err)
+	}
+	userID, _ := creation.Response.User.ID.(string)
+	userHandle, err := base64.RawURLEncoding.DecodeString(userID)
+	if err != nil {
+		t.Fatalf("decode user handle: %v", err)
+	}
+	a.userHandle = userHandle
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("encode public key: %v", err)
+	}
+
+	authData := a.authenticatorData(creation.Response.RelyingParty.ID, flagUserPresent|flagUserVerified|flagAttestedData)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatalf("encode attestation: %v", err)
+	}
+
+	return a.credentialJSON(t, map[string]string{
+		"clientDataJSON":    encodeBase64URL(clientData(t, "webauthn.create", creation.Response.Challenge, origin)),
+		"attestationObject": encodeBase64URL(attestation),
+	})
+}
+
+func (a *softwareAuthenticator) login(t *testing.T, options []byte, origin string) []byte {
+	t.Helper()
+
+	var assertion protocol.CredentialAssertion
+	if err := json.Unmarshal(options, &assertion); err != nil {
+		t.Fatalf("decode assertion options: %v", err)
+	}
+
+	a.signCount++
+	authData := a.authenticatorData(assertion.Response.RelyingPartyID, flagUserPresent|flagUserVerified)
+	clientDataJSON := clientData(t, "webauthn.get", assertion.Response.Challenge, origin)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign assertion: %v", err)
+	}
+
+	return a.credentialJSON(t, map[string]string{
+		"clientDataJSON":    encodeBase64URL(clientDataJSON),
+		"authenticatorData": encodeBase64URL(authData),
+		"signature":         encodeBase64URL(signature),
+		"userHandle":        encodeBase64URL(a.userHandle),
+	})
+}
+
+func (a *softwareAuthenticator) authenticatorData(rpID string, flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	authData := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(authData, a.signCount)
+}
+
+func (a *softwareAuthenticator) credentialJSON(t *testing.T, response map[string]string) []byte {
+	t.Helper()
+
+	encoded, err := json.Marshal(map[string]any{
+		"id":       encodeBase64URL(a.credentialID),
+		"rawId":    encodeBase64URL(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("encode credential: %v", err)
+	}
+	return encoded
+}
+
+func clientData(t *testing.T, ceremony string, challenge protocol.URLEncodedBase64, origin string) []byte {
+	t.Helper()
+
+	encoded, err := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   encodeBase64URL(challenge),
+		"origin":      origin,
+		"crossOrigin": false,
+	})
+	if err != nil {
+		t.Fatalf("encode client data: %v", err)
+	}
+	return encoded
+}
+
+func encodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func newPasskeyTestService(t *testing.T) *testService {
+	t.Helper()
+
+	s := newTestService(t)
+	s.cfg.WebAuthn = config.WebAuthnConfig{
+		RPID:           testRPID,
+		RPName:         "Auth Test",
+		Origins:        []string{testOrigin},
+		TimeoutSeconds: 60,
+	}
+
+	relyingParty, err := s.cfg.WebAuthn.RelyingParty()
+	if err != nil {
+		t.Fatalf("RelyingParty: %v", err)
+	}
+	s.relyingParty = relyingParty
+	return s
+}
+
+func (s *testService) accessToken(t *testing.T, user *entities.User) string {
+	t.Helper()
+
+	tokens, err := s.startSession(context.Background(), s.cfg, user, value_objects.ClientInfo{})
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	return tokens.AccessToken
+}
+
+func (s *testService) registerPasskey(t *testing.T, user *entities.User, authenticator *softwareAuthenticator) {
+	t.Helper()
+
+	ctx := context.Background()
+	accessToken := s.accessToken(t, user)
+
+	ceremony, err := s.BeginPasskeyRegistration(ctx, s.cfg, accessToken)
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration: %v", err)
+	}
+	response := authenticator.register(t, ceremony.Options, testOrigin)
+	if err = s.FinishPasskeyRegistration(ctx, s.cfg, accessToken, ceremony.ChallengeID, response); err != nil {
+		t.Fatalf("FinishPasskeyRegistration: %v", err)
+	}
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	ctx := context.Background()
+	s := newPasskeyTestService(t)
+	user := s.addUser("user@example.com")
+	authenticator := newSoftwareAuthenticator(t)
+
+	s.registerPasskey(t, user, authenticator)
+
+	stored, ok := s.passkeys.credentials[string(authenticator.credentialID)]
+	if !ok {
+		t.Fatal("passkey was not stored")
+	}
+	if stored.UserID != user.ID {
+		t.Errorf("passkey owner = %s, want %s", stored.UserID, user.ID)
+	}
+
+	ceremony, err := s.BeginPasskeyLogin(ctx, s.cfg)
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin: %v", err)
+	}
+	tokens, err := s.FinishPasskeyLogin(ctx, s.cfg, ceremony.ChallengeID, authenticator.login(t, ceremony.Options, testOrigin), value_objects.ClientInfo{})
+	if err != nil {
+		t.Fatalf("FinishPasskeyLogin: %v", err)
+	}
+
+	claims, err := s.authenticate(ctx, s.cfg, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if claims.UserID() != user.ID {
+		t.Errorf("logged in as %s, want %s", claims.UserID(), user.ID)
+	}
+	if stored.SignCount != authenticator.signCount {
+		t.Errorf("stored sign count = %d, want %d", stored.SignCount, authenticator.signCount)
+	}
+}
+
+func TestPasskeyRejectsReplayedChallenge(t *testing.T) {
+	ctx := context.Background()
+	s := newPasskeyTestService(t)
+	user := s.addUser("user@example.com")
+	authenticator := newSoftwareAuthenticator(t)
+
+	accessToken := s.accessToken(t, user)
+	registration, err := s.BeginPasskeyRegistration(ctx, s.cfg, accessToken)
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration: %v", err)
+	}
+	response := authenticator.register(t, registration.Options, testOrigin)
+	if err = s.FinishPasskeyRegistration(ctx, s.cfg, accessToken, registration.ChallengeID, response); err != nil {
+		t.Fatalf("FinishPasskeyRegistration: %v", err)
+	}
+	err = s.FinishPasskeyRegistration(ctx, s.cfg, accessToken, registration.ChallengeID, response)
+	if !errors.Is(err, service_errors.InvalidTokenError) {
+		t.Errorf("replayed registration: error = %v, want %v", err, service_errors.InvalidTokenError)
+	}
+
+	login, err := s.BeginPasskeyLogin(ctx, s.cfg)
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin: %v", err)
+	}
+	assertion := authenticator.login(t, login.Options, testOrigin)
+	if _, err = s.FinishPasskeyLogin(ctx, s.cfg, login.ChallengeID, assertion, value_objects.ClientInfo{}); err != nil {
+		t.Fatalf("FinishPasskeyLogin: %v", err)
+	}
+	_, err = s.FinishPasskeyLogin(ctx, s.cfg, login.ChallengeID, assertion, value_objects.ClientInfo{})
+	if !errors.Is(err, service_errors.InvalidTokenError) {
+		t.Errorf("replayed login: error = %v, want %v", err, service_errors.InvalidTokenError)
+	}
+}
+
+func TestPasskeyRejectsWrongOrigin(t *testing.T) {
+	ctx := context.Background()
+	s := newPasskeyTestService(t)
+	user := s.addUser("user@example.com")
+	authenticator := newSoftwareAuthenticator(t)
+	const phishingOrigin = "https://auth.test.example"
+
+	accessToken := s.accessToken(t, user)
+	registration, err := s.BeginPasskeyRegistration(ctx, s.cfg, accessToken)
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration: %v", err)
+	}
+	response := authenticator.register(t, registration.Options, phishingOrigin)
+	err = s.FinishPasskeyRegistration(ctx, s.cfg, accessToken, registration.ChallengeID, response)
+	if !errors.Is(err, service_errors.InvalidPasskeyError) {
+		t.Errorf("registration from %s: error = %v, want %v", phishingOrigin, err, service_errors.InvalidPasskeyError)
+	}
+	if len(s.passkeys.credentials) != 0 {
+		t.Fatal("passkey from a wrong origin was stored")
+	}
+
+	s.registerPasskey(t, user, authenticator)
+
+	login, err := s.BeginPasskeyLogin(ctx, s.cfg)
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin: %v", err)
+	}
+	assertion := authenticator.login(t, login.Options, phishingOrigin)
+	_, err = s.FinishPasskeyLogin(ctx, s.cfg, login.ChallengeID, assertion, value_objects.ClientInfo{})
+	if !errors.Is(err, service_errors.InvalidPasskeyError) {
+		t.Errorf("login from %s: error = %v, want %v", phishingOrigin, err, service_errors.InvalidPasskeyError)
+	}
+}
+
+func TestPasskeyLoginHonoursLockout(t *testing.T) {
+	ctx := context.Background()
+	s := newPasskeyTestService(t)
+	user := s.addUser("user@example.com")
+	authenticator := newSoftwareAuthenticator(t)
+	s.registerPasskey(t, user, authenticator)
+
+	passkeyLogin := func() error {
+		ceremony, err := s.BeginPasskeyLogin(ctx, s.cfg)
+		if err != nil {
+			t.Fatalf("BeginPasskeyLogin: %v", err)
+		}
+		_, err = s.FinishPasskeyLogin(ctx, s.cfg, ceremony.ChallengeID, authenticator.login(t, ceremony.Options, testOrigin), value_objects.ClientInfo{})
+		return err
+	}
+
+	for attempt := 1; attempt < s.cfg.Lockout.Threshold; attempt++ {
+		if err := s.loginFailed(ctx, s.cfg, user.Email, "", true); !errors.Is(err, service_errors.InvalidCredentialsError) {
+			t.Fatalf("loginFailed: %v", err)
+		}
+	}
+	if err := passkeyLogin(); err != nil {
+		t.Fatalf("FinishPasskeyLogin: %v", err)
+	}
+	if failures := s.attempts.failures(accountAttemptKey(user.Email)); failures != 0 {
+		t.Errorf("account failures after passkey login = %d, want 0", failures)
+	}
+
+	for attempt := 1; attempt <= s.cfg.Lockout.Threshold; attempt++ {
+		if err := s.loginFailed(ctx, s.cfg, user.Email, "", true); !errors.Is(err, service_errors.InvalidCredentialsError) {
+			t.Fatalf("loginFailed: %v", err)
+		}
+	}
+	if err := passkeyLogin(); !errors.Is(err, service_errors.AccountLockedError) {
+		t.Fatalf("FinishPasskeyLogin on a locked account: error = %v, want %v", err, service_errors.AccountLockedError)
+	}
+}
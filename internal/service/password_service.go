@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/password_policy"
+	"authService/internal/utils/service_errors"
+	"github.com/google/uuid"
+)
+
+func (u *UserServiceImpl) RequestPasswordReset(ctx context.Context, cfg *config.Config, email string) error {
+	user, err := u.userRepo.GetUserByEmail(ctx, cfg.Email.Normalize(email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		log.Printf("Error getting user: %v", err)
+		return service_errors.InternalServerError
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	issuedAt, err := u.verificationRepo.LastIssuedAt(ctx, user.ID, entities.VerificationPurposePasswordReset)
+	if err != nil {
+		log.Printf("Error getting last password reset token: %v", err)
+		return service_errors.InternalServerError
+	}
+	if time.Since(issuedAt) < cfg.Email.ResendCooldown() {
+		log.Printf("Password reset for user %s requested too often, skipping", user.ID)
+		return nil
+	}
+
+	if err = u.verificationRepo.InvalidateUserTokens(ctx, user.ID, entities.VerificationPurposePasswordReset); err != nil {
+		log.Printf("Error invalidating password reset tokens: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	return u.sendVerificationEmail(
+		ctx,
+		user.ID,
+		user.Email,
+		entities.VerificationPurposePasswordReset,
+		cfg.BrokerConstants.PasswordReset,
+		cfg.Email.ResetTTL(),
+	)
+}
+
+func (u *UserServiceImpl) ResetPassword(ctx context.Context, cfg *config.Config, token string, newPassword string) error {
+	tokenHash := hashing.HashVerificationToken(token)
+
+	pending, err := u.verificationRepo.GetActiveVerificationToken(ctx, entities.VerificationPurposePasswordReset, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service_errors.InvalidTokenError
+		}
+		log.Printf("Error getting password reset token: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	user, err := u.activeUser(ctx, pending.UserID)
+	if err != nil {
+		if errors.Is(err, service_errors.UserNotFoundError) {
+			return service_errors.InvalidTokenError
+		}
+		return err
+	}
+
+	currentHash, err := u.userRepo.GetPasswordHash(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error getting password hash: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err = u.checkNewPassword(ctx, cfg, user.ID, user.Email, currentHash, newPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := u.hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if _, err = u.verificationRepo.ConsumeVerificationToken(ctx, entities.VerificationPurposePasswordReset, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service_errors.InvalidTokenError
+		}
+		log.Printf("Error consuming password reset token: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err = u.replacePassword(ctx, cfg, user.ID, currentHash, hashedPassword); err != nil {
+		return err
+	}
+
+	if err = u.revokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	log.Printf("Password reset for user %s", user.ID)
+	return nil
+}
+
+func (u *UserServiceImpl) ChangePassword(
+	ctx context.Context,
+	cfg *config.Config,
+	accessToken string,
+	change *value_objects.ChangePasswordVO,
+	logoutOtherSessions bool,
+) error {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.activeUser(ctx, claims.UserID())
+	if err != nil {
+		return err
+	}
+
+	hashedPWD, err := u.userRepo.GetPasswordHash(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error getting password hash: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err = u.verifyAccountPassword(ctx, cfg, user, change.OldPassword, hashedPWD); err != nil {
+		return err
+	}
+
+	if err = u.checkNewPassword(ctx, cfg, user.ID, user.Email, hashedPWD, change.NewPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := u.hashPassword(change.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err = u.replacePassword(ctx, cfg, user.ID, hashedPWD, hashedPassword); err != nil {
+		return err
+	}
+
+	if logoutOtherSessions {
+		if err = u.revokeOtherSessions(ctx, user.ID, claims.SessionID()); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Password changed for user %s", user.ID)
+	return nil
+}
+
+func (u *UserServiceImpl) checkNewPassword(
+	ctx context.Context,
+	cfg *config.Config,
+	userID uuid.UUID,
+	email string,
+	currentHash []byte,
+	password string,
+) error {
+	if err := u.policy.Check(ctx, password, email); err != nil {
+		return err
+	}
+
+	historySize := cfg.Password.HistorySize
+	if historySize <= 0 {
+		return nil
+	}
+
+	previous, err := u.passwordHistoryRepo.GetRecentPasswords(ctx, userID, historySize-1)
+	if err != nil {
+		log.Printf("Error getting password history: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	for _, hash := range append([][]byte{currentHash}, previous...) {
+		err = u.passwords.Verify(password, hash)
+		if err == nil {
+			return password_policy.Violations{{
+				Rule:    password_policy.RuleReused,
+				Message: fmt.Sprintf("must differ from the last %d passwords", historySize),
+			}}
+		}
+		if !errors.Is(err, hashing.ErrInvalidPassword) {
+			log.Printf("Error checking password history for user %s: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) replacePassword(ctx context.Context, cfg *config.Config, userID uuid.UUID, currentHash []byte, hashedPassword []byte) error {
+	if err := u.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		log.Printf("Error updating password: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err := u.verificationRepo.InvalidateUserTokens(ctx, userID, entities.VerificationPurposePasswordReset); err != nil {
+		log.Printf("Error invalidating password reset tokens: %v", err)
+	}
+
+	if historySize := cfg.Password.HistorySize; historySize > 1 {
+		if err := u.passwordHistoryRepo.AddPasswordHistory(ctx, userID, currentHash); err != nil {
+			log.Printf("Error storing password history: %v", err)
+			return nil
+		}
+		if err := u.passwordHistoryRepo.PrunePasswordHistory(ctx, userID, historySize-1); err != nil {
+			log.Printf("Error pruning password history: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) hashPassword(password string) ([]byte, error) {
+	hashedPassword, err := u.passwords.Hash(password)
+	if err != nil {
+		if errors.Is(err, hashing.ErrPasswordTooLong) {
+			return nil, service_errors.PasswordTooLongError
+		}
+		log.Printf("Error hashing password: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	return hashedPassword, nil
+}
+
+func (u *UserServiceImpl) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	hashedPassword, err := u.passwords.Hash(password)
+	if err != nil {
+		log.Printf("Error rehashing password for user %s: %v", userID, err)
+		return
+	}
+
+	if err = u.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		log.Printf("Error storing rehashed password for user %s: %v", userID, err)
+		return
+	}
+
+	log.Printf("Password hash upgraded for user %s", userID)
+}
+
+func (u *UserServiceImpl) revokeOtherSessions(ctx context.Context, userID uuid.UUID, currentSessionID uuid.UUID) error {
+	if currentSessionID == uuid.Nil {
+		return u.revokeAllSessions(ctx, userID)
+	}
+
+	if err := u.refreshTokenRepo.RevokeOtherSessionTokens(ctx, userID, currentSessionID); err != nil {
+		log.Printf("Error revoking refresh tokens of other sessions: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err := u.sessionRepo.RevokeOtherSessions(ctx, userID, currentSessionID); err != nil {
+		log.Printf("Error revoking other sessions: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestChangePasswordLocksAccountOnWrongOldPassword(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+	s.setPassword(t, user, "correct horse battery staple")
+	token := s.accessToken(t, user)
+
+	wrong := &value_objects.ChangePasswordVO{OldPassword: "wrong password", NewPassword: "another long passphrase"}
+	for attempt := 1; attempt <= s.cfg.Lockout.Threshold; attempt++ {
+		if err := s.ChangePassword(ctx, s.cfg, token, wrong, false); !errors.Is(err, service_errors.InvalidCredentialsError) {
+			t.Fatalf("attempt %d: ChangePassword error = %v, want %v", attempt, err, service_errors.InvalidCredentialsError)
+		}
+	}
+	if failures := s.attempts.failures(accountAttemptKey(user.Email)); failures != s.cfg.Lockout.Threshold {
+		t.Errorf("account failures = %d, want %d", failures, s.cfg.Lockout.Threshold)
+	}
+
+	right := &value_objects.ChangePasswordVO{OldPassword: "correct horse battery staple", NewPassword: "another long passphrase"}
+	if err := s.ChangePassword(ctx, s.cfg, token, right, false); !errors.Is(err, service_errors.AccountLockedError) {
+		t.Fatalf("ChangePassword on a locked account: error = %v, want %v", err, service_errors.AccountLockedError)
+	}
+}
+
+func TestLoginUpgradesLegacyPasswordHash(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+	s.setPassword(t, user, "correct horse battery staple")
+
+	legacy, err := hashing.NewBcryptHasher(bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("NewBcryptHasher: %v", err)
+	}
+	current, err := hashing.NewArgon2idHasher(hashing.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("NewArgon2idHasher: %v", err)
+	}
+	s.passwords = hashing.NewPasswordHashers(current, legacy)
+
+	credentials := &value_objects.UserVO{Email: user.Email, Password: "correct horse battery staple"}
+	if _, err = s.Login(ctx, s.cfg, credentials, value_objects.ClientInfo{}); err != nil {
+		t.Fatalf("Login with a bcrypt hash: %v", err)
+	}
+	if !current.Identifies(s.users.passwords[user.ID]) {
+		t.Fatalf("stored hash %s was not upgraded to argon2id", s.users.passwords[user.ID])
+	}
+
+	if _, err = s.Login(ctx, s.cfg, credentials, value_objects.ClientInfo{}); err != nil {
+		t.Fatalf("Login with the upgraded hash: %v", err)
+	}
+	if s.users.passwordUpdates != 1 {
+		t.Errorf("password hash stored %d times, want 1", s.users.passwordUpdates)
+	}
+
+	credentials.Password = "wrong password"
+	if _, err = s.Login(ctx, s.cfg, credentials, value_objects.ClientInfo{}); !errors.Is(err, service_errors.InvalidCredentialsError) {
+		t.Fatalf("Login with a wrong password: error = %v, want %v", err, service_errors.InvalidCredentialsError)
+	}
+	if s.users.passwordUpdates != 1 {
+		t.Error("a failed login rewrote the password hash")
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"authService/internal/config"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+	"github.com/google/uuid"
+)
+
+func (u *UserServiceImpl) RegenerateRecoveryCodes(ctx context.Context, cfg *config.Config, accessToken string, password string) ([]string, error) {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.activeUser(ctx, claims.UserID())
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPWD, err := u.userRepo.GetPasswordHash(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error getting password hash: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	if err = u.verifyAccountPassword(ctx, cfg, user, password, hashedPWD); err != nil {
+		return nil, err
+	}
+
+	credential, err := u.confirmedTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, service_errors.MFANotEnrolledError
+	}
+
+	codes, err := u.issueRecoveryCodes(ctx, cfg, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Recovery codes regenerated for user %s", user.ID)
+	return codes, nil
+}
+
+func (u *UserServiceImpl) issueRecoveryCodes(ctx context.Context, cfg *config.Config, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, cfg.MFA.RecoveryCodes)
+	hashes := make([][]byte, 0, cfg.MFA.RecoveryCodes)
+	for range cfg.MFA.RecoveryCodes {
+		code, codeHash, err := hashing.GenerateRecoveryCode()
+		if err != nil {
+			log.Printf("Error generating recovery code: %v", err)
+			return nil, service_errors.InternalServerError
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, codeHash)
+	}
+
+	if err := u.recoveryCodeRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		log.Printf("Error storing recovery codes: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	return codes, nil
+}
+
+func (u *UserServiceImpl) useRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	consumed, err := u.recoveryCodeRepo.ConsumeRecoveryCode(ctx, userID, hashing.HashRecoveryCode(code))
+	if err != nil {
+		log.Printf("Error consuming recovery code: %v", err)
+		return service_errors.InternalServerError
+	}
+	if !consumed {
+		return service_errors.InvalidMFACodeError
+	}
+
+	remaining, err := u.recoveryCodeRepo.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		log.Printf("Error counting recovery codes: %v", err)
+		return nil
+	}
+	log.Printf("Recovery code used by user %s, %d left", userID, remaining)
+
+	return nil
+}
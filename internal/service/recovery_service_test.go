@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/service_errors"
+)
+
+func TestRecoveryCodeIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+	s.enableTOTP(t, user)
+
+	codes, err := s.issueRecoveryCodes(ctx, s.cfg, user.ID)
+	if err != nil {
+		t.Fatalf("issueRecoveryCodes: %v", err)
+	}
+	if len(codes) != s.cfg.MFA.RecoveryCodes {
+		t.Fatalf("issued %d codes, want %d", len(codes), s.cfg.MFA.RecoveryCodes)
+	}
+
+	verification := value_objects.MFAVerification{Challenge: s.mfaChallenge(t, user), RecoveryCode: codes[0]}
+	if _, err = s.VerifyMFA(ctx, s.cfg, verification, value_objects.ClientInfo{}); err != nil {
+		t.Fatalf("VerifyMFA with a recovery code: %v", err)
+	}
+
+	verification = value_objects.MFAVerification{Challenge: s.mfaChallenge(t, user), RecoveryCode: codes[0]}
+	if _, err = s.VerifyMFA(ctx, s.cfg, verification, value_objects.ClientInfo{}); !errors.Is(err, service_errors.InvalidMFACodeError) {
+		t.Fatalf("VerifyMFA with a used recovery code: error = %v, want %v", err, service_errors.InvalidMFACodeError)
+	}
+
+	if remaining := len(s.recovery.codes[user.ID]); remaining != len(codes)-1 {
+		t.Errorf("%d codes left, want %d", remaining, len(codes)-1)
+	}
+
+	verification = value_objects.MFAVerification{Challenge: s.mfaChallenge(t, user), RecoveryCode: codes[1]}
+	if _, err = s.VerifyMFA(ctx, s.cfg, verification, value_objects.ClientInfo{}); err != nil {
+		t.Fatalf("VerifyMFA with another recovery code: %v", err)
+	}
+}
+
+func TestIssueRecoveryCodesReplacesOldCodes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+
+	old, err := s.issueRecoveryCodes(ctx, s.cfg, user.ID)
+	if err != nil {
+		t.Fatalf("issueRecoveryCodes: %v", err)
+	}
+	if _, err = s.issueRecoveryCodes(ctx, s.cfg, user.ID); err != nil {
+		t.Fatalf("issueRecoveryCodes: %v", err)
+	}
+
+	if err = s.useRecoveryCode(ctx, user.ID, old[0]); !errors.Is(err, service_errors.InvalidMFACodeError) {
+		t.Errorf("useRecoveryCode with a replaced code: error = %v, want %v", err, service_errors.InvalidMFACodeError)
+	}
+}
+
+func TestRegenerateRecoveryCodesCountsWrongPasswords(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+	s.setPassword(t, user, "correct horse battery staple")
+	s.enableTOTP(t, user)
+	token := s.accessToken(t, user)
+
+	if _, err := s.RegenerateRecoveryCodes(ctx, s.cfg, token, "wrong password"); !errors.Is(err, service_errors.InvalidCredentialsError) {
+		t.Fatalf("RegenerateRecoveryCodes error = %v, want %v", err, service_errors.InvalidCredentialsError)
+	}
+	if failures := s.attempts.failures(accountAttemptKey(user.Email)); failures != 1 {
+		t.Errorf("account failures = %d, want 1", failures)
+	}
+
+	codes, err := s.RegenerateRecoveryCodes(ctx, s.cfg, token, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes: %v", err)
+	}
+	if len(codes) != s.cfg.MFA.RecoveryCodes {
+		t.Errorf("issued %d codes, want %d", len(codes), s.cfg.MFA.RecoveryCodes)
+	}
+	if failures := s.attempts.failures(accountAttemptKey(user.Email)); failures != 0 {
+		t.Errorf("account failures after success = %d, want 0", failures)
+	}
+
+	for attempt := 1; attempt <= s.cfg.Lockout.Threshold; attempt++ {
+		if _, err = s.RegenerateRecoveryCodes(ctx, s.cfg, token, "wrong password"); !errors.Is(err, service_errors.InvalidCredentialsError) {
+			t.Fatalf("attempt %d: RegenerateRecoveryCodes error = %v, want %v", attempt, err, service_errors.InvalidCredentialsError)
+		}
+	}
+	if _, err = s.RegenerateRecoveryCodes(ctx, s.cfg, token, "correct horse battery staple"); !errors.Is(err, service_errors.AccountLockedError) {
+		t.Fatalf("RegenerateRecoveryCodes on a locked account: error = %v, want %v", err, service_errors.AccountLockedError)
+	}
+}
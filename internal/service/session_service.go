@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/service_errors"
+	"github.com/google/uuid"
+)
+
+func (u *UserServiceImpl) ListSessions(ctx context.Context, cfg *config.Config, accessToken string) ([]value_objects.SessionInfo, error) {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.sessionRepo.ListUserSessions(ctx, claims.UserID())
+	if err != nil {
+		log.Printf("Error listing sessions: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+
+	result := make([]value_objects.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, value_objects.SessionInfo{
+			ID:              session.ID,
+			UserAgent:       session.UserAgent,
+			IPAddress:       session.IPAddress,
+			CreatedAt:       session.CreatedAt,
+			LastRefreshedAt: session.LastRefreshedAt,
+			Current:         session.ID == claims.SessionID(),
+		})
+	}
+
+	return result, nil
+}
+
+func (u *UserServiceImpl) RevokeSession(ctx context.Context, cfg *config.Config, accessToken string, sessionID uuid.UUID) error {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return err
+	}
+
+	session, err := u.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service_errors.SessionNotFoundError
+		}
+		log.Printf("Error getting session: %v", err)
+		return service_errors.InternalServerError
+	}
+	if session.UserID != claims.UserID() || session.RevokedAt != nil {
+		return service_errors.SessionNotFoundError
+	}
+
+	return u.endSession(ctx, session.ID)
+}
+
+func (u *UserServiceImpl) startSession(
+	ctx context.Context,
+	cfg *config.Config,
+	user *entities.User,
+	client value_objects.ClientInfo,
+) (value_objects.AuthResponse, error) {
+	audience, err := audienceFor(cfg, client.ClientID)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	session := &entities.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+	}
+	if err = u.sessionRepo.InsertSession(ctx, session); err != nil {
+		log.Printf("Error creating session: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	refreshToken := newRefreshToken(cfg, user.ID, uuid.New(), &session.ID, nil)
+	tokens, err := u.signTokens(cfg, user, refreshToken, audience)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	if err = u.refreshTokenRepo.InsertRefreshToken(ctx, refreshToken); err != nil {
+		log.Printf("Error storing refresh token: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	tokens.SessionID = session.ID
+	return tokens, nil
+}
+
+func (u *UserServiceImpl) endSession(ctx context.Context, sessionID uuid.UUID) error {
+	if err := u.refreshTokenRepo.RevokeSessionTokens(ctx, sessionID); err != nil {
+		log.Printf("Error revoking session refresh tokens: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err := u.sessionRepo.RevokeSession(ctx, sessionID); err != nil {
+		log.Printf("Error revoking session: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) checkSession(ctx context.Context, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		return nil
+	}
+
+	session, err := u.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service_errors.TokenRevokedError
+		}
+		log.Printf("Error getting session: %v", err)
+		return service_errors.InternalServerError
+	}
+	if session.RevokedAt != nil {
+		return service_errors.TokenRevokedError
+	}
+
+	return nil
+}
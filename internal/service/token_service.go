@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"authService/internal/config"
+	"authService/internal/domain/entities"
+	"authService/internal/domain/value_objects"
+	"authService/internal/utils/hashing"
+	"authService/internal/utils/service_errors"
+	"github.com/google/uuid"
+)
+
+func (u *UserServiceImpl) Refresh(ctx context.Context, cfg *config.Config, refreshToken string) (value_objects.AuthResponse, error) {
+	claims, err := hashing.ParseAndValidate(refreshToken, u.keys, validationOptions(cfg, hashing.RefreshTokenType))
+	if err != nil {
+		return value_objects.AuthResponse{}, tokenError(err)
+	}
+
+	// Refresh tokens issued to OAuth clients are refreshed only through /token,
+	// where confidential clients have to authenticate.
+	for _, audience := range claims.Audience {
+		_, err = u.oauthClientRepo.GetClient(ctx, audience)
+		if err == nil {
+			return value_objects.AuthResponse{}, service_errors.InvalidTokenError
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error getting oauth client: %v", err)
+			return value_objects.AuthResponse{}, service_errors.InternalServerError
+		}
+	}
+
+	return u.rotateRefreshToken(ctx, cfg, claims)
+}
+
+func (u *UserServiceImpl) rotateRefreshToken(ctx context.Context, cfg *config.Config, claims *hashing.Claims) (value_objects.AuthResponse, error) {
+	stored, err := u.refreshTokenRepo.GetRefreshToken(ctx, claims.TokenID())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return value_objects.AuthResponse{}, service_errors.InvalidTokenError
+		}
+		log.Printf("Error getting refresh token: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	if stored.UserID != claims.UserID() || stored.RevokedAt != nil {
+		return value_objects.AuthResponse{}, service_errors.InvalidTokenError
+	}
+	if stored.UsedAt != nil {
+		return value_objects.AuthResponse{}, u.revokeReusedFamily(ctx, stored)
+	}
+
+	user, err := u.activeUser(ctx, claims.UserID())
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+	if claims.Version != user.TokenVersion {
+		return value_objects.AuthResponse{}, service_errors.TokenRevokedError
+	}
+	if stored.SessionID != nil {
+		if err = u.checkSession(ctx, *stored.SessionID); err != nil {
+			return value_objects.AuthResponse{}, err
+		}
+	}
+
+	next := newRefreshToken(cfg, user.ID, stored.FamilyID, stored.SessionID, &stored.JTI)
+	tokens, err := u.signTokens(cfg, user, next, claims.Audience)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	rotated, err := u.refreshTokenRepo.RotateRefreshToken(ctx, stored.JTI, next)
+	if err != nil {
+		log.Printf("Error rotating refresh token: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+	if !rotated {
+		return value_objects.AuthResponse{}, u.revokeReusedFamily(ctx, stored)
+	}
+
+	if stored.SessionID != nil {
+		if err = u.sessionRepo.TouchSession(ctx, *stored.SessionID); err != nil {
+			log.Printf("Error updating session: %v", err)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (u *UserServiceImpl) Introspect(
+	ctx context.Context,
+	cfg *config.Config,
+	callerSecret string,
+	token string,
+) (value_objects.TokenIntrospection, error) {
+	inactive := value_objects.TokenIntrospection{Active: false}
+	if err := authenticateIntrospectionCaller(cfg, callerSecret); err != nil {
+		return inactive, err
+	}
+
+	claims, err := hashing.ParseAndValidate(token, u.keys, validationOptions(cfg, ""))
+	if err != nil {
+		return inactive, nil
+	}
+
+	switch claims.Type {
+	case hashing.AccessTokenType:
+		err = u.checkAccessClaims(ctx, claims)
+	case hashing.RefreshTokenType:
+		err = u.checkRefreshClaims(ctx, claims)
+	default:
+		return inactive, nil
+	}
+	if err != nil {
+		if errors.Is(err, service_errors.InternalServerError) {
+			return inactive, err
+		}
+		return inactive, nil
+	}
+
+	introspection := value_objects.TokenIntrospection{
+		Active:    true,
+		TokenID:   claims.TokenID(),
+		UserID:    claims.UserID(),
+		Type:      claims.Type,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+	}
+	if claims.IssuedAt != nil {
+		introspection.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return introspection, nil
+}
+
+func (u *UserServiceImpl) Logout(ctx context.Context, cfg *config.Config, accessToken string, refreshToken string) error {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if refreshToken != "" {
+		if err = u.revokeRefreshFamily(ctx, cfg, claims.UserID(), refreshToken); err != nil {
+			return err
+		}
+	}
+
+	if sessionID := claims.SessionID(); sessionID != uuid.Nil {
+		if err = u.endSession(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+
+	if err = u.denylistRepo.DenyToken(ctx, claims.TokenID(), claims.ExpiresAt.Time); err != nil {
+		log.Printf("Error denying access token: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) LogoutAll(ctx context.Context, cfg *config.Config, accessToken string) error {
+	claims, err := u.authenticate(ctx, cfg, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return u.revokeAllSessions(ctx, claims.UserID())
+}
+
+func (u *UserServiceImpl) revokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := u.refreshTokenRepo.RevokeUserTokens(ctx, userID); err != nil {
+		log.Printf("Error revoking user refresh tokens: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err := u.sessionRepo.RevokeUserSessions(ctx, userID); err != nil {
+		log.Printf("Error revoking user sessions: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err := u.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		log.Printf("Error incrementing token version: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) authenticate(ctx context.Context, cfg *config.Config, accessToken string) (*hashing.Claims, error) {
+	claims, err := hashing.ParseAndValidate(accessToken, u.keys, validationOptions(cfg, hashing.AccessTokenType))
+	if err != nil {
+		return nil, tokenError(err)
+	}
+
+	if err = u.checkAccessClaims(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (u *UserServiceImpl) checkAccessClaims(ctx context.Context, claims *hashing.Claims) error {
+	denied, err := u.denylistRepo.IsTokenDenied(ctx, claims.TokenID())
+	if err != nil {
+		log.Printf("Error checking token denylist: %v", err)
+		return service_errors.InternalServerError
+	}
+	if denied {
+		return service_errors.TokenRevokedError
+	}
+
+	user, err := u.activeUser(ctx, claims.UserID())
+	if err != nil {
+		return err
+	}
+	if claims.Version != user.TokenVersion {
+		return service_errors.TokenRevokedError
+	}
+
+	return u.checkSession(ctx, claims.SessionID())
+}
+
+func (u *UserServiceImpl) checkRefreshClaims(ctx context.Context, claims *hashing.Claims) error {
+	stored, err := u.refreshTokenRepo.GetRefreshToken(ctx, claims.TokenID())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service_errors.InvalidTokenError
+		}
+		log.Printf("Error getting refresh token: %v", err)
+		return service_errors.InternalServerError
+	}
+	if stored.UserID != claims.UserID() || stored.RevokedAt != nil || stored.UsedAt != nil {
+		return service_errors.TokenRevokedError
+	}
+
+	user, err := u.activeUser(ctx, claims.UserID())
+	if err != nil {
+		return err
+	}
+	if claims.Version != user.TokenVersion {
+		return service_errors.TokenRevokedError
+	}
+
+	return u.checkSession(ctx, claims.SessionID())
+}
+
+func (u *UserServiceImpl) revokeRefreshFamily(ctx context.Context, cfg *config.Config, userID uuid.UUID, refreshToken string) error {
+	claims, err := hashing.ParseAndValidate(refreshToken, u.keys, validationOptions(cfg, hashing.RefreshTokenType))
+	if err != nil {
+		if errors.Is(err, hashing.ErrTokenExpired) {
+			return nil
+		}
+		return service_errors.InvalidTokenError
+	}
+	if claims.UserID() != userID {
+		return service_errors.InvalidTokenError
+	}
+
+	stored, err := u.refreshTokenRepo.GetRefreshToken(ctx, claims.TokenID())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		log.Printf("Error getting refresh token: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	if err = u.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		log.Printf("Error revoking refresh token family: %v", err)
+		return service_errors.InternalServerError
+	}
+
+	return nil
+}
+
+func (u *UserServiceImpl) revokeReusedFamily(ctx context.Context, token *entities.RefreshToken) error {
+	log.Printf("Refresh token reuse detected for user %s, revoking family %s", token.UserID, token.FamilyID)
+	if err := u.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		log.Printf("Error revoking refresh token family: %v", err)
+		return service_errors.InternalServerError
+	}
+	return service_errors.RefreshTokenReuseError
+}
+
+func (u *UserServiceImpl) activeUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := u.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service_errors.UserNotFoundError
+		}
+		log.Printf("Error getting user: %v", err)
+		return nil, service_errors.InternalServerError
+	}
+	if !user.IsActive {
+		return nil, service_errors.UserNotFoundError
+	}
+
+	return user, nil
+}
+
+func (u *UserServiceImpl) signTokens(cfg *config.Config, user *entities.User, refreshToken *entities.RefreshToken, audience []string) (value_objects.AuthResponse, error) {
+	options := hashing.TokenOptions{
+		UserID:     user.ID,
+		RefreshID:  refreshToken.JTI,
+		Version:    user.TokenVersion,
+		Issuer:     cfg.JWT.Issuer,
+		Audience:   audience,
+		AccessTTL:  cfg.JWT.AccessTTL(),
+		RefreshTTL: cfg.JWT.RefreshTTL(),
+	}
+	if refreshToken.SessionID != nil {
+		options.SessionID = *refreshToken.SessionID
+	}
+
+	tokens, err := hashing.CreateAccessRefreshTokens(options, u.keys.SigningKey())
+	if err != nil {
+		log.Printf("Token generation error: %v", err)
+		return value_objects.AuthResponse{}, service_errors.InternalServerError
+	}
+
+	return value_objects.AuthResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func newRefreshToken(cfg *config.Config, userID uuid.UUID, familyID uuid.UUID, sessionID *uuid.UUID, parentJTI *uuid.UUID) *entities.RefreshToken {
+	return &entities.RefreshToken{
+		JTI:       uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		SessionID: sessionID,
+		ParentJTI: parentJTI,
+		ExpiresAt: time.Now().Add(cfg.JWT.RefreshTTL()),
+	}
+}
+
+func validationOptions(cfg *config.Config, tokenType string) hashing.ValidationOptions {
+	return hashing.ValidationOptions{
+		Type:      tokenType,
+		Issuer:    cfg.JWT.Issuer,
+		Audiences: cfg.JWT.Audiences,
+	}
+}
+
+// authenticateIntrospectionCaller checks the calling service against
+// INTROSPECTION_SECRETS: RFC 7662 requires introspection callers to be
+// authenticated, so token state is not exposed to anyone holding a token.
+func authenticateIntrospectionCaller(cfg *config.Config, callerSecret string) error {
+	if len(cfg.IntrospectionSecrets) == 0 {
+		return service_errors.IntrospectionUnavailableError
+	}
+	if callerSecret == "" {
+		return service_errors.MissingTokenError
+	}
+
+	matched := 0
+	for _, secret := range cfg.IntrospectionSecrets {
+		matched |= subtle.ConstantTimeCompare([]byte(callerSecret), []byte(secret))
+	}
+	if matched != 1 {
+		return service_errors.InvalidCallerCredentialsError
+	}
+	return nil
+}
+
+func audienceFor(cfg *config.Config, clientID string) ([]string, error) {
+	if clientID == "" {
+		if len(cfg.JWT.Audiences) == 0 {
+			return nil, nil
+		}
+		return cfg.JWT.Audiences[:1], nil
+	}
+
+	for _, audience := range cfg.JWT.Audiences {
+		if audience == clientID {
+			return []string{clientID}, nil
+		}
+	}
+	return nil, service_errors.UnknownClientError
+}
+
+func tokenError(err error) error {
+	if errors.Is(err, hashing.ErrTokenExpired) {
+		return service_errors.TokenExpiredError
+	}
+	return service_errors.InvalidTokenError
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"authService/internal/utils/service_errors"
+)
+
+func TestIntrospectAuthenticatesCaller(t *testing.T) {
+	s := newTestService(t)
+	user := s.addUser("user@example.com")
+	token := s.accessToken(t, user)
+	s.cfg.IntrospectionSecrets = []string{"billing-secret", "orders-secret"}
+
+	tests := []struct {
+		name    string
+		secrets []string
+		caller  string
+		wantErr error
+	}{
+		{name: "known caller", caller: "orders-secret"},
+		{name: "wrong secret", caller: "orders-secre", wantErr: service_errors.InvalidCallerCredentialsError},
+		{name: "no secret", caller: "", wantErr: service_errors.MissingTokenError},
+		{name: "user access token", caller: token, wantErr: service_errors.InvalidCallerCredentialsError},
+		{name: "not configured", secrets: []string{}, caller: "orders-secret", wantErr: service_errors.IntrospectionUnavailableError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *s.cfg
+			if tt.secrets != nil {
+				cfg.IntrospectionSecrets = tt.secrets
+			}
+
+			introspection, err := s.Introspect(context.Background(), &cfg, tt.caller, token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Introspect error = %v, want %v", err, tt.wantErr)
+			}
+			if introspection.Active != (tt.wantErr == nil) {
+				t.Errorf("Introspect active = %v, want %v", introspection.Active, tt.wantErr == nil)
+			}
+			if tt.wantErr == nil && introspection.UserID != user.ID {
+				t.Errorf("Introspect sub = %s, want %s", introspection.UserID, user.ID)
+			}
+		})
+	}
+}
@@ -5,95 +5,253 @@ import (
 	"database/sql"
 	"errors"
 	"log"
+	"time"
 
 	"authService/internal/config"
+	"authService/internal/domain/entities"
 	"authService/internal/domain/repositories"
 	"authService/internal/domain/value_objects"
 	"authService/internal/utils/hashing"
+	"authService/internal/utils/password_policy"
 	"authService/internal/utils/service_errors"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
 )
 
+const accountExistsEvent = "account_exists"
+
 type UserService interface {
-	Register(ctx context.Context, userRegistry *value_objects.UserVO) error
-	Login(ctx context.Context, cfg *config.Config, userLogin *value_objects.UserVO) (value_objects.AuthResponse, error)
+	Register(ctx context.Context, cfg *config.Config, userRegistry *value_objects.UserVO) error
+	Login(ctx context.Context, cfg *config.Config, userLogin *value_objects.UserVO, client value_objects.ClientInfo) (value_objects.AuthResponse, error)
+	Refresh(ctx context.Context, cfg *config.Config, refreshToken string) (value_objects.AuthResponse, error)
+	Introspect(ctx context.Context, cfg *config.Config, callerSecret string, token string) (value_objects.TokenIntrospection, error)
+	Logout(ctx context.Context, cfg *config.Config, accessToken string, refreshToken string) error
+	LogoutAll(ctx context.Context, cfg *config.Config, accessToken string) error
+	ListSessions(ctx context.Context, cfg *config.Config, accessToken string) ([]value_objects.SessionInfo, error)
+	RevokeSession(ctx context.Context, cfg *config.Config, accessToken string, sessionID uuid.UUID) error
+	ConfirmEmail(ctx context.Context, token string) error
+	ResendConfirmation(ctx context.Context, cfg *config.Config, email string) error
+	RequestPasswordReset(ctx context.Context, cfg *config.Config, email string) error
+	ResetPassword(ctx context.Context, cfg *config.Config, token string, newPassword string) error
+	ChangePassword(ctx context.Context, cfg *config.Config, accessToken string, change *value_objects.ChangePasswordVO, logoutOtherSessions bool) error
+	EnrollTOTP(ctx context.Context, cfg *config.Config, accessToken string) (value_objects.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, cfg *config.Config, accessToken string, code string) ([]string, error)
+	VerifyMFA(ctx context.Context, cfg *config.Config, verification value_objects.MFAVerification, client value_objects.ClientInfo) (value_objects.AuthResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, cfg *config.Config, accessToken string, password string) ([]string, error)
+	BeginPasskeyRegistration(ctx context.Context, cfg *config.Config, accessToken string) (value_objects.PasskeyCeremony, error)
+	FinishPasskeyRegistration(ctx context.Context, cfg *config.Config, accessToken string, challengeID uuid.UUID, response []byte) error
+	BeginPasskeyLogin(ctx context.Context, cfg *config.Config) (value_objects.PasskeyCeremony, error)
+	FinishPasskeyLogin(ctx context.Context, cfg *config.Config, challengeID uuid.UUID, response []byte, client value_objects.ClientInfo) (value_objects.AuthResponse, error)
+	RequestLoginCode(ctx context.Context, cfg *config.Config, email string, clientID string) error
+	LoginWithCode(ctx context.Context, cfg *config.Config, email string, code string, client value_objects.ClientInfo) (value_objects.AuthResponse, error)
+	LoginWithMagicLink(ctx context.Context, cfg *config.Config, token string, client value_objects.ClientInfo) (value_objects.AuthResponse, error)
+	RegisterOAuthClient(ctx context.Context, cfg *config.Config, registration value_objects.OAuthClientRegistration) (value_objects.OAuthClientCredentials, error)
+	ValidateAuthorizationRequest(ctx context.Context, request value_objects.AuthorizationRequest) error
+	ValidateOAuthSession(ctx context.Context, cfg *config.Config, sessionToken string) (time.Time, error)
+	Authorize(ctx context.Context, cfg *config.Config, sessionToken string, request value_objects.AuthorizationRequest) (string, error)
+	GrantOAuthConsent(ctx context.Context, cfg *config.Config, sessionToken string, request value_objects.AuthorizationRequest) error
+	ExchangeAuthorizationCode(ctx context.Context, cfg *config.Config, exchange value_objects.CodeExchange, client value_objects.ClientInfo) (value_objects.AuthResponse, error)
+	RefreshOAuthToken(ctx context.Context, cfg *config.Config, credentials value_objects.OAuthClientCredentials, refreshToken string) (value_objects.AuthResponse, error)
 }
 
-func NewUserService(repository repositories.UserRepository, brokerRepo repositories.RabbitRepository) UserService {
+func NewUserService(
+	repository repositories.UserRepository,
+	brokerRepo repositories.RabbitRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	denylistRepo repositories.TokenDenylistRepository,
+	sessionRepo repositories.SessionRepository,
+	verificationRepo repositories.VerificationTokenRepository,
+	passwordHistoryRepo repositories.PasswordHistoryRepository,
+	loginAttemptRepo repositories.LoginAttemptRepository,
+	totpRepo repositories.TOTPRepository,
+	recoveryCodeRepo repositories.RecoveryCodeRepository,
+	passkeyRepo repositories.PasskeyRepository,
+	challengeRepo repositories.WebAuthnChallengeRepository,
+	oauthClientRepo repositories.OAuthClientRepository,
+	authCodeRepo repositories.AuthorizationCodeRepository,
+	keys *hashing.Keyring,
+	passwords hashing.PasswordHasher,
+	policy *password_policy.Policy,
+	secretBox *hashing.SecretBox,
+	relyingParty *webauthn.WebAuthn,
+) UserService {
+	dummyHash, err := passwords.Hash(uuid.NewString())
+	if err != nil {
+		log.Printf("Error generating dummy password hash: %v", err)
+	}
+
 	return &UserServiceImpl{
-		userRepo:   repository,
-		brokerRepo: brokerRepo,
+		userRepo:            repository,
+		brokerRepo:          brokerRepo,
+		refreshTokenRepo:    refreshTokenRepo,
+		denylistRepo:        denylistRepo,
+		sessionRepo:         sessionRepo,
+		verificationRepo:    verificationRepo,
+		passwordHistoryRepo: passwordHistoryRepo,
+		loginAttemptRepo:    loginAttemptRepo,
+		totpRepo:            totpRepo,
+		recoveryCodeRepo:    recoveryCodeRepo,
+		passkeyRepo:         passkeyRepo,
+		challengeRepo:       challengeRepo,
+		oauthClientRepo:     oauthClientRepo,
+		authCodeRepo:        authCodeRepo,
+		keys:                keys,
+		passwords:           passwords,
+		policy:              policy,
+		secretBox:           secretBox,
+		relyingParty:        relyingParty,
+		dummyHash:           dummyHash,
 	}
 }
 
 type UserServiceImpl struct {
-	userRepo   repositories.UserRepository
-	brokerRepo repositories.RabbitRepository
+	userRepo            repositories.UserRepository
+	brokerRepo          repositories.RabbitRepository
+	refreshTokenRepo    repositories.RefreshTokenRepository
+	denylistRepo        repositories.TokenDenylistRepository
+	sessionRepo         repositories.SessionRepository
+	verificationRepo    repositories.VerificationTokenRepository
+	passwordHistoryRepo repositories.PasswordHistoryRepository
+	loginAttemptRepo    repositories.LoginAttemptRepository
+	totpRepo            repositories.TOTPRepository
+	recoveryCodeRepo    repositories.RecoveryCodeRepository
+	passkeyRepo         repositories.PasskeyRepository
+	challengeRepo       repositories.WebAuthnChallengeRepository
+	oauthClientRepo     repositories.OAuthClientRepository
+	authCodeRepo        repositories.AuthorizationCodeRepository
+	keys                *hashing.Keyring
+	passwords           hashing.PasswordHasher
+	policy              *password_policy.Policy
+	secretBox           *hashing.SecretBox
+	relyingParty        *webauthn.WebAuthn
+	dummyHash           []byte
 }
 
-func (u *UserServiceImpl) Register(ctx context.Context, userRegistry *value_objects.UserVO) error {
-	exists, err := u.userRepo.CheckUserExist(ctx, userRegistry.Email)
+func (u *UserServiceImpl) Register(ctx context.Context, cfg *config.Config, userRegistry *value_objects.UserVO) error {
+	if err := u.policy.Check(ctx, userRegistry.Password, userRegistry.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := u.hashPassword(userRegistry.Password)
+	if err != nil {
+		return err
+	}
+
+	normalizedEmail := cfg.Email.Normalize(userRegistry.Email)
+
+	exists, err := u.userRepo.CheckUserExist(ctx, normalizedEmail)
 	if err != nil {
 		log.Printf("Error checking user existence: %v", err)
 		return service_errors.InternalServerError
 	}
 
 	if exists {
-		return service_errors.UserAlreadyExistsError
+		return u.alreadyRegistered(cfg, userRegistry.Email)
 	}
 
-	hashedPassword, err := hashing.HashPassword(userRegistry.Password)
+	userID, err := u.userRepo.InsertUser(ctx, userRegistry.Email, normalizedEmail, hashedPassword)
 	if err != nil {
-		log.Printf("Error hashing password: %v", err)
-		return service_errors.InternalServerError
-	}
-
-	if err = u.userRepo.InsertUser(ctx, userRegistry.Email, hashedPassword); err != nil {
+		if errors.Is(err, service_errors.UserAlreadyExistsError) {
+			return u.alreadyRegistered(cfg, userRegistry.Email)
+		}
 		log.Printf("Error inserting user: %v", err)
 		return service_errors.InternalServerError
 	}
 
-	go func() {
-		if err = u.brokerRepo.CreateEmailMSG(userRegistry.Email); err != nil {
-			log.Printf("Error creating email message: %v", err)
-		}
-	}()
+	if err = u.sendConfirmation(ctx, cfg, userID, userRegistry.Email); err != nil {
+		log.Printf("Error sending confirmation email to %s: %v", userRegistry.Email, err)
+	}
 
 	log.Printf("User registered successfully: %s", userRegistry.Email)
 	return nil
 }
 
-func (u *UserServiceImpl) Login(ctx context.Context, cfg *config.Config, userLogin *value_objects.UserVO) (value_objects.AuthResponse, error) {
-	userID, hashedPWD, err := u.userRepo.GetUserCredentials(ctx, userLogin.Email)
+func (u *UserServiceImpl) Login(ctx context.Context, cfg *config.Config, userLogin *value_objects.UserVO, client value_objects.ClientInfo) (value_objects.AuthResponse, error) {
+	email := cfg.Email.Normalize(userLogin.Email)
+
+	if err := u.checkLoginLock(ctx, email, client.IPAddress); err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	userID, hashedPWD, err := u.userRepo.GetUserCredentials(ctx, email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return value_objects.AuthResponse{}, service_errors.InvalidCredentialsError
+			u.verifyDummyPassword(userLogin.Password)
+			return value_objects.AuthResponse{}, u.loginFailed(ctx, cfg, email, client.IPAddress, false)
 		}
 		log.Printf("Error getting user credentials: %v", err)
 		return value_objects.AuthResponse{}, service_errors.InternalServerError
 	}
 
-	if err := hashing.VerifyPassword(userLogin.Password, hashedPWD); err != nil {
+	if err := u.passwords.Verify(userLogin.Password, hashedPWD); err != nil {
 		if errors.Is(err, hashing.ErrInvalidPassword) {
-			return value_objects.AuthResponse{}, service_errors.InvalidCredentialsError
+			return value_objects.AuthResponse{}, u.loginFailed(ctx, cfg, email, client.IPAddress, true)
 		}
 		log.Printf("Password verification error: %v", err)
 		return value_objects.AuthResponse{}, service_errors.InternalServerError
 	}
 
-	tokens, err := hashing.CreateAccessRefreshTokens(
-		userID,
-		cfg.JWT.AccessExpireMinutes,
-		cfg.JWT.RefreshExpireDays,
-		cfg.JWT.JWTSecret,
-		cfg.JWT.Algorithm,
-	)
+	user, err := u.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
-		log.Printf("Token generation error: %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return value_objects.AuthResponse{}, service_errors.InvalidCredentialsError
+		}
+		log.Printf("Error getting user: %v", err)
 		return value_objects.AuthResponse{}, service_errors.InternalServerError
 	}
+	if err = checkLoginAllowed(user); err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+
+	if u.passwords.NeedsRehash(hashedPWD) {
+		u.rehashPassword(ctx, user.ID, userLogin.Password)
+	}
+
+	return u.completeLogin(ctx, cfg, user, client)
+}
+
+func (u *UserServiceImpl) completeLogin(ctx context.Context, cfg *config.Config, user *entities.User, client value_objects.ClientInfo) (value_objects.AuthResponse, error) {
+	credential, err := u.confirmedTOTP(ctx, user.ID)
+	if err != nil {
+		return value_objects.AuthResponse{}, err
+	}
+	if credential != nil {
+		return u.startMFAChallenge(cfg, user, client)
+	}
+	u.resetLoginFailures(ctx, cfg.Email.Normalize(user.Email))
 
-	return value_objects.AuthResponse{
-		AccessToken:  tokens["access"],
-		RefreshToken: tokens["refresh"],
-	}, nil
+	return u.startSession(ctx, cfg, user, client)
+}
+
+func checkLoginAllowed(user *entities.User) error {
+	if user.EmailConfirmedAt == nil {
+		return service_errors.EmailNotConfirmedError
+	}
+	if !user.IsActive {
+		return service_errors.InvalidCredentialsError
+	}
+	return nil
+}
+
+func (u *UserServiceImpl) verifyDummyPassword(password string) {
+	if u.dummyHash == nil {
+		return
+	}
+	_ = u.passwords.Verify(password, u.dummyHash)
+}
+
+func (u *UserServiceImpl) alreadyRegistered(cfg *config.Config, email string) error {
+	if cfg.RegisterHideExisting {
+		u.publishAccountExists(cfg, email)
+		return nil
+	}
+	return service_errors.UserAlreadyExistsError
+}
+
+func (u *UserServiceImpl) publishAccountExists(cfg *config.Config, email string) {
+	message := value_objects.EmailMessage{
+		Type:  accountExistsEvent,
+		Email: email,
+	}
+	u.publishEmail(cfg.BrokerConstants.AccountExists, message)
 }
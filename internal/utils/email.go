@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var plusAddressingDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"outlook.com":    true,
+	"hotmail.com":    true,
+	"live.com":       true,
+	"icloud.com":     true,
+	"me.com":         true,
+	"proton.me":      true,
+	"protonmail.com": true,
+	"fastmail.com":   true,
+	"yandex.ru":      true,
+}
+
+// NormalizeEmail returns the identity key of an email address: trimmed,
+// Unicode NFC and lowercased. With providerRules it also drops "+tag"
+// suffixes for providers that ignore them and dots in Gmail local parts.
+func NormalizeEmail(email string, providerRules bool) string {
+	normalized := strings.ToLower(norm.NFC.String(strings.TrimSpace(email)))
+	if !providerRules {
+		return normalized
+	}
+
+	at := strings.LastIndex(normalized, "@")
+	if at <= 0 {
+		return normalized
+	}
+	local, domain := normalized[:at], normalized[at+1:]
+
+	if !plusAddressingDomains[domain] {
+		return normalized
+	}
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain
+}
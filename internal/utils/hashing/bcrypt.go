@@ -0,0 +1,69 @@
+package hashing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptMaxPasswordBytes is the longest input bcrypt accepts; longer
+// passwords need a pepper, which pre-hashes them to a fixed length.
+const BcryptMaxPasswordBytes = 72
+
+var bcryptPrefixes = [][]byte{[]byte("$2a$"), []byte("$2b$"), []byte("$2y$")}
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) (*BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &BcryptHasher{Cost: cost}, nil
+}
+
+func (b *BcryptHasher) Hash(password string) ([]byte, error) {
+	if len(password) > BcryptMaxPasswordBytes {
+		return nil, ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return hash, nil
+}
+
+func (b *BcryptHasher) Verify(password string, hash []byte) error {
+	err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if err != nil {
+		switch {
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return ErrInvalidPassword
+		case errors.Is(err, bcrypt.ErrHashTooShort):
+			return ErrInvalidHash
+		default:
+			return fmt.Errorf("password verification failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (b *BcryptHasher) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost != b.Cost
+}
+
+func (b *BcryptHasher) Identifies(hash []byte) bool {
+	for _, prefix := range bcryptPrefixes {
+		if bytes.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -8,43 +8,179 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	AccessTokenType     = "access"
+	RefreshTokenType    = "refresh"
+	MFAPendingTokenType = "mfa_pending"
+	MagicLinkTokenType  = "magic_link"
+)
+
 var (
 	AlgorithmNotAllowed = errors.New("algorithm Not Allowed")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrInvalidTokenType = errors.New("invalid token type")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
 )
 
-func CreateAccessRefreshTokens(id uuid.UUID, accessMin int, refreshDays int, secretKey string, algorithm string) (map[string]string, error) {
-	accessJWTClaims := jwt.MapClaims{
-		"sub":  id,
-		"exp":  time.Now().Add(time.Minute * time.Duration(accessMin)).Unix(),
-		"iat":  time.Now().Unix(),
-		"type": "access",
+type Claims struct {
+	jwt.RegisteredClaims
+	Type    string `json:"type"`
+	Version int    `json:"ver"`
+	Sid     string `json:"sid,omitempty"`
+
+	tokenID   uuid.UUID
+	userID    uuid.UUID
+	sessionID uuid.UUID
+}
+
+func (c *Claims) TokenID() uuid.UUID {
+	return c.tokenID
+}
+
+func (c *Claims) UserID() uuid.UUID {
+	return c.userID
+}
+
+func (c *Claims) SessionID() uuid.UUID {
+	return c.sessionID
+}
+
+type TokenPair struct {
+	AccessToken   string
+	RefreshToken  string
+	AccessClaims  *Claims
+	RefreshClaims *Claims
+}
+
+type TokenOptions struct {
+	UserID     uuid.UUID
+	RefreshID  uuid.UUID
+	SessionID  uuid.UUID
+	Version    int
+	Issuer     string
+	Audience   []string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+type ValidationOptions struct {
+	Type      string
+	Issuer    string
+	Audiences []string
+}
+
+func CreateAccessRefreshTokens(opts TokenOptions, key *SigningKey) (TokenPair, error) {
+	now := time.Now()
+
+	accessClaims := newClaims(opts, uuid.New(), AccessTokenType, now, opts.AccessTTL)
+	refreshClaims := newClaims(opts, opts.RefreshID, RefreshTokenType, now, opts.RefreshTTL)
+
+	accessString, err := key.sign(accessClaims)
+	if err != nil {
+		return TokenPair{}, err
 	}
 
-	refreshJWTClaims := jwt.MapClaims{
-		"sub":  id,
-		"exp":  time.Now().Add(time.Hour * 24 * time.Duration(refreshDays)).Unix(),
-		"iat":  time.Now().Unix(),
-		"type": "refresh",
+	refreshString, err := key.sign(refreshClaims)
+	if err != nil {
+		return TokenPair{}, err
 	}
 
-	if algorithm == "HS256" {
-		accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessJWTClaims)
-		accessString, err := accessToken.SignedString([]byte(secretKey))
-		if err != nil {
-			return nil, err
-		}
+	return TokenPair{
+		AccessToken:   accessString,
+		RefreshToken:  refreshString,
+		AccessClaims:  accessClaims,
+		RefreshClaims: refreshClaims,
+	}, nil
+}
 
-		refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshJWTClaims)
-		refreshString, err := refreshToken.SignedString([]byte(secretKey))
-		if err != nil {
-			return nil, err
+// CreateMFAChallenge signs a short-lived mfa_pending token that proves the
+// password step succeeded; it cannot be used as an access token.
+func CreateMFAChallenge(opts TokenOptions, ttl time.Duration, key *SigningKey) (string, error) {
+	claims := newClaims(opts, uuid.New(), MFAPendingTokenType, time.Now(), ttl)
+	return key.sign(claims)
+}
+
+// CreateMagicLink signs a single-use login link token; tokenID is the id of
+// the verification token row that tracks its use.
+func CreateMagicLink(opts TokenOptions, tokenID uuid.UUID, ttl time.Duration, key *SigningKey) (string, error) {
+	claims := newClaims(opts, tokenID, MagicLinkTokenType, time.Now(), ttl)
+	return key.sign(claims)
+}
+
+func ParseAndValidate(tokenString string, keys *Keyring, opts ValidationOptions) (*Claims, error) {
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{AlgorithmHS256, AlgorithmRS256, AlgorithmES256, AlgorithmEdDSA}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if opts.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(opts.Issuer))
+	}
+	if len(opts.Audiences) > 0 {
+		parserOptions = append(parserOptions, jwt.WithAudience(opts.Audiences...))
+	}
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, keys.keyfunc, parserOptions...); err != nil {
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return nil, ErrTokenExpired
+		case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+			return nil, ErrInvalidIssuer
+		case errors.Is(err, jwt.ErrTokenInvalidAudience):
+			return nil, ErrInvalidAudience
+		default:
+			return nil, ErrInvalidToken
 		}
+	}
+
+	if opts.Type != "" && claims.Type != opts.Type {
+		return nil, ErrInvalidTokenType
+	}
 
-		return map[string]string{
-			"access":  accessString,
-			"refresh": refreshString,
-		}, nil
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims.userID = userID
+	claims.tokenID = tokenID
+
+	if claims.Sid != "" {
+		if claims.sessionID, err = uuid.Parse(claims.Sid); err != nil {
+			return nil, ErrInvalidToken
+		}
 	}
 
-	return nil, AlgorithmNotAllowed
+	return claims, nil
+}
+
+func newClaims(opts TokenOptions, tokenID uuid.UUID, tokenType string, now time.Time, ttl time.Duration) *Claims {
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID.String(),
+			Subject:   opts.UserID.String(),
+			Issuer:    opts.Issuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Type:      tokenType,
+		Version:   opts.Version,
+		tokenID:   tokenID,
+		userID:    opts.UserID,
+		sessionID: opts.SessionID,
+	}
+	if len(opts.Audience) > 0 {
+		claims.Audience = opts.Audience
+	}
+	if opts.SessionID != uuid.Nil {
+		claims.Sid = opts.SessionID.String()
+	}
+	return claims
 }
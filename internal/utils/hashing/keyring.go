@@ -0,0 +1,508 @@
+package hashing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	keyFileExt       = ".pem"
+	keyIDTimeFormat  = "20060102T150405Z"
+	reloadCooldown   = time.Minute
+	rotationLockFile = ".rotation.lock"
+	rotationLockTTL  = 5 * time.Minute
+)
+
+// JWKSMaxAge is how long verifiers may cache the published key set; a rotated
+// key is prepublished for at least this long before it signs.
+const JWKSMaxAge = 5 * time.Minute
+
+var (
+	ErrNoSigningKey         = errors.New("keyring has no signing key")
+	ErrKeyringNotPersistent = errors.New("keyring is not backed by a key directory")
+)
+
+// Keyring holds the active signing key together with retired keys that are
+// still accepted for verification until retention has passed since they were
+// replaced, so rotating keys does not invalidate outstanding tokens. Freshly
+// rotated keys stay pending for the prepublish period: they are published for
+// verification but do not sign yet, so verifiers with a cached JWKS see them
+// before the first token signed with them arrives.
+type Keyring struct {
+	mu         sync.RWMutex
+	active     *SigningKey
+	pending    []*SigningKey
+	retired    []retiredKey
+	retention  time.Duration
+	prepublish time.Duration
+	dir        string
+	algorithm  string
+	reloaded   time.Time
+}
+
+type retiredKey struct {
+	key       *SigningKey
+	retiredAt time.Time
+}
+
+func NewKeyring(active *SigningKey, retention time.Duration) *Keyring {
+	return &Keyring{
+		active:    active,
+		retention: retention,
+		algorithm: active.Algorithm,
+	}
+}
+
+// LoadKeyring reads every *.pem file in dir, using the file name as kid; the
+// creation time is the timestamp the kid starts with. The newest key older
+// than prepublish signs, older keys verify until retention has passed since
+// the next key replaced them. An empty directory is seeded with a freshly
+// generated key that signs immediately.
+func LoadKeyring(dir string, algorithm string, retention time.Duration, prepublish time.Duration) (*Keyring, error) {
+	keyring := &Keyring{
+		retention:  retention,
+		prepublish: prepublish,
+		dir:        dir,
+		algorithm:  algorithm,
+	}
+
+	if err := keyring.Reload(); err != nil {
+		if !errors.Is(err, ErrNoSigningKey) {
+			return nil, err
+		}
+		if _, err = keyring.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return keyring, nil
+}
+
+func (k *Keyring) AddVerificationKey(key *SigningKey, retiredAt time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.retired = append(k.retired, retiredKey{key: key, retiredAt: retiredAt})
+}
+
+func (k *Keyring) SigningKey() *SigningKey {
+	k.mu.RLock()
+	active := k.active
+	due := len(k.pending) > 0 && !time.Now().Before(k.ActivatesAt(k.pending[0]))
+	k.mu.RUnlock()
+
+	if due {
+		return k.activatePending()
+	}
+	return active
+}
+
+// ActivatesAt returns when a rotated key starts signing tokens.
+func (k *Keyring) ActivatesAt(key *SigningKey) time.Time {
+	return key.CreatedAt.Add(k.prepublish)
+}
+
+func (k *Keyring) activatePending() *SigningKey {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	for len(k.pending) > 0 && !now.Before(k.ActivatesAt(k.pending[0])) {
+		next := k.pending[0]
+		k.retired = append(k.retired, retiredKey{key: k.active, retiredAt: k.ActivatesAt(next)})
+		k.active = next
+		k.pending = k.pending[1:]
+		log.Printf("JWT signing key rotated: kid=%s alg=%s", next.ID, next.Algorithm)
+	}
+
+	return k.active
+}
+
+func (k *Keyring) Lookup(kid string) (*SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.active != nil && k.active.ID == kid {
+		return k.active, true
+	}
+	for _, pending := range k.pending {
+		if pending.ID == kid {
+			return pending, true
+		}
+	}
+
+	now := time.Now()
+	for _, retired := range k.retired {
+		if retired.key.ID == kid && now.Before(retired.retiredAt.Add(k.retention)) {
+			return retired.key, true
+		}
+	}
+
+	return nil, false
+}
+
+func (k *Keyring) VerificationKeys() []*SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]*SigningKey, 0, len(k.pending)+len(k.retired)+1)
+	for i := len(k.pending) - 1; i >= 0; i-- {
+		keys = append(keys, k.pending[i])
+	}
+	if k.active != nil {
+		keys = append(keys, k.active)
+	}
+
+	now := time.Now()
+	for i := len(k.retired) - 1; i >= 0; i-- {
+		if now.Before(k.retired[i].retiredAt.Add(k.retention)) {
+			keys = append(keys, k.retired[i].key)
+		}
+	}
+
+	return keys
+}
+
+func (k *Keyring) Rotate() (*SigningKey, error) {
+	if k.dir == "" {
+		return nil, ErrKeyringNotPersistent
+	}
+
+	now := time.Now()
+	kid, err := newKeyID(now)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := GenerateSigningKey(kid, k.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedAt = now.UTC().Truncate(time.Second)
+
+	if err = writeKeyFile(k.dir, key); err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.active == nil {
+		k.active = key
+		log.Printf("JWT signing key created: kid=%s alg=%s", key.ID, key.Algorithm)
+		return key, nil
+	}
+
+	k.pending = append(k.pending, key)
+	log.Printf("JWT signing key published: kid=%s alg=%s activates_at=%s",
+		key.ID, key.Algorithm, k.ActivatesAt(key).Format(time.RFC3339))
+	return key, nil
+}
+
+func (k *Keyring) Prune() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	kept := k.retired[:0]
+	for _, retired := range k.retired {
+		if now.Before(retired.retiredAt.Add(k.retention)) {
+			kept = append(kept, retired)
+			continue
+		}
+
+		log.Printf("JWT verification key dropped: kid=%s", retired.key.ID)
+		if k.dir != "" {
+			path := filepath.Join(k.dir, retired.key.ID+keyFileExt)
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Error removing key file %s: %v", path, err)
+			}
+		}
+	}
+	k.retired = kept
+}
+
+// Reload re-reads the keyring directory so keys rotated by another replica
+// sharing the directory are picked up.
+func (k *Keyring) Reload() error {
+	if k.dir == "" {
+		return nil
+	}
+
+	keys, err := readKeyDir(k.dir)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return ErrNoSigningKey
+	}
+
+	now := time.Now()
+	activeIndex := 0
+	for i := 1; i < len(keys); i++ {
+		if !now.Before(k.ActivatesAt(keys[i])) {
+			activeIndex = i
+		}
+	}
+
+	retired := make([]retiredKey, 0, activeIndex)
+	for i := 0; i < activeIndex; i++ {
+		retired = append(retired, retiredKey{key: keys[i], retiredAt: k.ActivatesAt(keys[i+1])})
+	}
+
+	k.mu.Lock()
+	k.active = keys[activeIndex]
+	k.pending = keys[activeIndex+1:]
+	k.retired = retired
+	k.reloaded = now
+	k.mu.Unlock()
+
+	k.Prune()
+	return nil
+}
+
+func (k *Keyring) StartRotation(ctx context.Context, interval time.Duration, checkEvery time.Duration) {
+	ticker := time.NewTicker(checkEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Reload(); err != nil {
+				log.Printf("Error reloading keyring: %v", err)
+			}
+
+			if interval > 0 && k.rotationDue(interval) {
+				if err := k.rotateExclusive(interval); err != nil {
+					log.Printf("Error rotating signing key: %v", err)
+				}
+			}
+
+			k.Prune()
+		}
+	}
+}
+
+// rotationDue reports whether the newest key, pending or not, is older than
+// interval, so a key waiting for activation does not trigger another rotation.
+func (k *Keyring) rotationDue(interval time.Duration) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	newest := k.active
+	if len(k.pending) > 0 {
+		newest = k.pending[len(k.pending)-1]
+	}
+	return newest != nil && time.Since(newest.CreatedAt) >= interval
+}
+
+// rotateExclusive rotates under a lock file in the key directory so replicas
+// sharing it do not each create a new key.
+func (k *Keyring) rotateExclusive(interval time.Duration) error {
+	unlock, acquired, err := acquireRotationLock(k.dir)
+	if err != nil || !acquired {
+		return err
+	}
+	defer unlock()
+
+	if err = k.Reload(); err != nil {
+		return err
+	}
+	if !k.rotationDue(interval) {
+		return nil
+	}
+
+	_, err = k.Rotate()
+	return err
+}
+
+func (k *Keyring) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.Lookup(kid)
+	if !ok && k.reloadStale() {
+		key, ok = k.Lookup(kid)
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, AlgorithmNotAllowed
+	}
+	return key.verifyKey, nil
+}
+
+func (k *Keyring) reloadStale() bool {
+	k.mu.RLock()
+	stale := k.dir != "" && time.Since(k.reloaded) >= reloadCooldown
+	k.mu.RUnlock()
+
+	if !stale {
+		return false
+	}
+	if err := k.Reload(); err != nil {
+		log.Printf("Error reloading keyring: %v", err)
+		return false
+	}
+	return true
+}
+
+func readKeyDir(dir string) ([]*SigningKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*SigningKey, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+
+		kid := strings.TrimSuffix(entry.Name(), keyFileExt)
+		createdAt, err := keyCreatedAt(kid)
+		if err != nil {
+			return nil, fmt.Errorf("load key %s: %w", entry.Name(), err)
+		}
+
+		pemBytes, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := NewSigningKeyFromPEM(kid, "", pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("load key %s: %w", entry.Name(), err)
+		}
+		key.CreatedAt = createdAt
+
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func writeKeyFile(dir string, key *SigningKey) error {
+	pemBytes, err := key.MarshalPEM()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".key-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Error removing temporary key file: %v", err)
+		}
+	}()
+
+	if _, err = tmp.Write(pemBytes); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, key.ID+keyFileExt))
+}
+
+// acquireRotationLock creates the lock file with its timestamp in a single
+// link call, so another replica never observes an empty lock.
+func acquireRotationLock(dir string) (func(), bool, error) {
+	path := filepath.Join(dir, rotationLockFile)
+
+	acquired, err := createLockFile(dir, path)
+	if err == nil && !acquired && rotationLockStale(path) {
+		log.Printf("Removing stale key rotation lock %s", path)
+		if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, false, err
+		}
+		acquired, err = createLockFile(dir, path)
+	}
+	if err != nil || !acquired {
+		return nil, false, err
+	}
+
+	return func() {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Error removing key rotation lock: %v", err)
+		}
+	}, true, nil
+}
+
+func createLockFile(dir string, path string) (bool, error) {
+	tmp, err := os.CreateTemp(dir, ".lock-*")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Error removing temporary lock file: %v", err)
+		}
+	}()
+
+	if _, err = tmp.WriteString(time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err = tmp.Close(); err != nil {
+		return false, err
+	}
+
+	if err = os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func rotationLockStale(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist)
+	}
+	lockedAt, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(content)))
+	return err != nil || time.Since(lockedAt) >= rotationLockTTL
+}
+
+func newKeyID(createdAt time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return createdAt.UTC().Format(keyIDTimeFormat) + "-" + hex.EncodeToString(suffix), nil
+}
+
+func keyCreatedAt(kid string) (time.Time, error) {
+	timestamp, _, _ := strings.Cut(kid, "-")
+	createdAt, err := time.Parse(keyIDTimeFormat, timestamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("kid %q does not start with a creation timestamp", kid)
+	}
+	return createdAt, nil
+}
@@ -0,0 +1,153 @@
+package hashing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestKey(t *testing.T, algorithm string) *SigningKey {
+	t.Helper()
+
+	kid, err := newKeyID(time.Now())
+	if err != nil {
+		t.Fatalf("newKeyID: %v", err)
+	}
+	key, err := GenerateSigningKey(kid, algorithm)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey(%s): %v", algorithm, err)
+	}
+	return key
+}
+
+func signAccessToken(t *testing.T, key *SigningKey) string {
+	t.Helper()
+
+	tokens, err := CreateAccessRefreshTokens(TokenOptions{
+		UserID:     uuid.New(),
+		RefreshID:  uuid.New(),
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	}, key)
+	if err != nil {
+		t.Fatalf("CreateAccessRefreshTokens: %v", err)
+	}
+	return tokens.AccessToken
+}
+
+func TestKeyringLookup(t *testing.T) {
+	const retention = time.Hour
+
+	active := newTestKey(t, AlgorithmES256)
+	retired := newTestKey(t, AlgorithmES256)
+	expired := newTestKey(t, AlgorithmES256)
+
+	keyring := NewKeyring(active, retention)
+	keyring.AddVerificationKey(retired, time.Now().Add(-retention/2))
+	keyring.AddVerificationKey(expired, time.Now().Add(-2*retention))
+
+	tests := []struct {
+		name string
+		kid  string
+		want *SigningKey
+	}{
+		{name: "active", kid: active.ID, want: active},
+		{name: "retired within retention", kid: retired.ID, want: retired},
+		{name: "retired past retention", kid: expired.ID},
+		{name: "unknown", kid: "unknown"},
+		{name: "empty", kid: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := keyring.Lookup(tt.kid)
+			if ok != (tt.want != nil) || got != tt.want {
+				t.Errorf("Lookup(%q) = %v, %v, want %v", tt.kid, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAndValidateSelectsKeyByKid(t *testing.T) {
+	oldKey := newTestKey(t, AlgorithmRS256)
+	newKey := newTestKey(t, AlgorithmEdDSA)
+
+	keyring := NewKeyring(newKey, time.Hour)
+	keyring.AddVerificationKey(oldKey, time.Now())
+
+	for _, key := range []*SigningKey{oldKey, newKey} {
+		if _, err := ParseAndValidate(signAccessToken(t, key), keyring, ValidationOptions{Type: AccessTokenType}); err != nil {
+			t.Errorf("token signed with kid %s: %v", key.ID, err)
+		}
+	}
+
+	stranger := newTestKey(t, AlgorithmRS256)
+	_, err := ParseAndValidate(signAccessToken(t, stranger), keyring, ValidationOptions{Type: AccessTokenType})
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with an unknown key: error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestKeyringRotationPrepublishesKey(t *testing.T) {
+	keyring, err := LoadKeyring(t.TempDir(), AlgorithmES256, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+	first := keyring.SigningKey()
+
+	rotated, err := keyring.Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	if got := keyring.SigningKey(); got.ID != first.ID {
+		t.Errorf("signing kid right after rotation = %s, want %s", got.ID, first.ID)
+	}
+	if _, ok := keyring.Lookup(rotated.ID); !ok {
+		t.Error("pending key is not available for verification")
+	}
+	if kids := keyIDs(keyring.JWKS()); !kids[rotated.ID] || !kids[first.ID] {
+		t.Errorf("JWKS kids = %v, want both %s and %s", kids, first.ID, rotated.ID)
+	}
+}
+
+func TestKeyringRotationKeepsOldTokensValid(t *testing.T) {
+	dir := t.TempDir()
+	keyring, err := LoadKeyring(dir, AlgorithmEdDSA, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+	first := keyring.SigningKey()
+	token := signAccessToken(t, first)
+
+	rotated, err := keyring.Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if got := keyring.SigningKey(); got.ID != rotated.ID {
+		t.Fatalf("signing kid after activation = %s, want %s", got.ID, rotated.ID)
+	}
+	if _, err = ParseAndValidate(token, keyring, ValidationOptions{Type: AccessTokenType}); err != nil {
+		t.Errorf("token signed before rotation: %v", err)
+	}
+
+	// Another replica sharing the directory sees the same keys.
+	replica, err := LoadKeyring(dir, AlgorithmEdDSA, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("LoadKeyring replica: %v", err)
+	}
+	for _, signed := range []string{token, signAccessToken(t, rotated)} {
+		if _, err = ParseAndValidate(signed, replica, ValidationOptions{Type: AccessTokenType}); err != nil {
+			t.Errorf("replica rejects a token of the shared keys: %v", err)
+		}
+	}
+}
+
+func keyIDs(set JWKSet) map[string]bool {
+	kids := make(map[string]bool, len(set.Keys))
+	for _, key := range set.Keys {
+		kids[key.Kid] = true
+	}
+	return kids
+}
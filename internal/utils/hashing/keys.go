@@ -0,0 +1,208 @@
+package hashing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+
+	hmacSecretPEMType = "HMAC SECRET KEY"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid signing key")
+	ErrUnknownKey = errors.New("unknown key id")
+)
+
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func NewHMACSigningKey(kid string, secret []byte) (*SigningKey, error) {
+	if len(secret) == 0 {
+		return nil, ErrInvalidKey
+	}
+	if kid == "" {
+		sum := sha256.Sum256(append([]byte("kid:"), secret...))
+		kid = base64.RawURLEncoding.EncodeToString(sum[:8])
+	}
+
+	return &SigningKey{
+		ID:        kid,
+		Algorithm: AlgorithmHS256,
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}, nil
+}
+
+func NewSigningKeyFromPEM(kid string, algorithm string, pemBytes []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidKey)
+	}
+
+	if block.Type == hmacSecretPEMType {
+		if algorithm != "" && algorithm != AlgorithmHS256 {
+			return nil, fmt.Errorf("%w: HMAC secret cannot be used with %s", AlgorithmNotAllowed, algorithm)
+		}
+		return NewHMACSigningKey(kid, block.Bytes)
+	}
+
+	privateKey, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSigningKey(kid, algorithm, privateKey)
+}
+
+func NewSigningKey(kid string, algorithm string, privateKey crypto.Signer) (*SigningKey, error) {
+	keyAlgorithm, method, err := algorithmForKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if algorithm != "" && algorithm != keyAlgorithm {
+		return nil, fmt.Errorf("%w: %s key cannot be used with %s", AlgorithmNotAllowed, keyAlgorithm, algorithm)
+	}
+
+	publicKey := privateKey.Public()
+	if kid == "" {
+		kid, err = keyThumbprint(publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &SigningKey{
+		ID:        kid,
+		Algorithm: keyAlgorithm,
+		method:    method,
+		signKey:   privateKey,
+		verifyKey: publicKey,
+	}, nil
+}
+
+func GenerateSigningKey(kid string, algorithm string) (*SigningKey, error) {
+	var privateKey crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmHS256:
+		secret := make([]byte, 32)
+		if _, err = rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return NewHMACSigningKey(kid, secret)
+	case AlgorithmRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, AlgorithmNotAllowed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSigningKey(kid, algorithm, privateKey)
+}
+
+func (k *SigningKey) MarshalPEM() ([]byte, error) {
+	if k.Algorithm == AlgorithmHS256 {
+		return pem.EncodeToMemory(&pem.Block{Type: hmacSecretPEMType, Bytes: k.signKey.([]byte)}), nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.signKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func (k *SigningKey) PublicKey() crypto.PublicKey {
+	if k.Algorithm == AlgorithmHS256 {
+		return nil
+	}
+	return k.verifyKey
+}
+
+func (k *SigningKey) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.signKey)
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported private key type %T", ErrInvalidKey, key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported PEM block %q", ErrInvalidKey, block.Type)
+	}
+}
+
+func algorithmForKey(privateKey crypto.Signer) (string, jwt.SigningMethod, error) {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < 2048 {
+			return "", nil, fmt.Errorf("%w: RSA keys must be at least 2048 bits", ErrInvalidKey)
+		}
+		return AlgorithmRS256, jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", nil, fmt.Errorf("%w: only P-256 ECDSA keys are supported", ErrInvalidKey)
+		}
+		return AlgorithmES256, jwt.SigningMethodES256, nil
+	case ed25519.PrivateKey:
+		return AlgorithmEdDSA, jwt.SigningMethodEdDSA, nil
+	default:
+		return "", nil, fmt.Errorf("%w: unsupported private key type %T", ErrInvalidKey, privateKey)
+	}
+}
+
+func keyThumbprint(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:8]), nil
+}
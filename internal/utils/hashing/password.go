@@ -1,47 +1,67 @@
 package hashing
 
-import (
-	"errors"
-	"fmt"
+import "errors"
 
-	"golang.org/x/crypto/bcrypt"
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
 )
 
 var (
 	ErrPasswordTooLong = errors.New("password too long")
 	ErrInvalidHash     = errors.New("invalid hash format")
 	ErrInvalidPassword = errors.New("invalid password")
+	ErrUnknownHash     = errors.New("unknown password hash algorithm")
 )
 
-func HashPassword(password string) ([]byte, error) {
-	if len(password) > 64 {
-		return nil, ErrPasswordTooLong
-	}
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	Verify(password string, hash []byte) error
+	NeedsRehash(hash []byte) bool
+	Identifies(hash []byte) bool
+}
+
+// PasswordHashers hashes new passwords with the current hasher and verifies
+// existing hashes with whichever known hasher produced them.
+type PasswordHashers struct {
+	current PasswordHasher
+	known   []PasswordHasher
+}
 
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+func NewPasswordHashers(current PasswordHasher, legacy ...PasswordHasher) *PasswordHashers {
+	return &PasswordHashers{
+		current: current,
+		known:   append([]PasswordHasher{current}, legacy...),
 	}
+}
 
-	return bytes, nil
+func (p *PasswordHashers) Hash(password string) ([]byte, error) {
+	return p.current.Hash(password)
 }
 
-func VerifyPassword(password string, hash []byte) error {
+func (p *PasswordHashers) Verify(password string, hash []byte) error {
 	if len(hash) == 0 {
 		return ErrInvalidHash
 	}
 
-	err := bcrypt.CompareHashAndPassword(hash, []byte(password))
-	if err != nil {
-		switch {
-		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
-			return ErrInvalidPassword
-		case errors.Is(err, bcrypt.ErrHashTooShort):
-			return ErrInvalidHash
-		default:
-			return fmt.Errorf("password verification failed: %w", err)
+	for _, hasher := range p.known {
+		if hasher.Identifies(hash) {
+			return hasher.Verify(password, hash)
 		}
 	}
 
-	return nil
+	return ErrUnknownHash
+}
+
+func (p *PasswordHashers) NeedsRehash(hash []byte) bool {
+	return !p.current.Identifies(hash) || p.current.NeedsRehash(hash)
+}
+
+func (p *PasswordHashers) Identifies(hash []byte) bool {
+	for _, hasher := range p.known {
+		if hasher.Identifies(hash) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,190 @@
+package hashing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Deliberately cheap parameters; they only keep the tests fast.
+var testArgon2Params = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func newTestArgon2id(t *testing.T, params Argon2Params) *Argon2idHasher {
+	t.Helper()
+
+	hasher, err := NewArgon2idHasher(params)
+	if err != nil {
+		t.Fatalf("NewArgon2idHasher: %v", err)
+	}
+	return hasher
+}
+
+func newTestBcrypt(t *testing.T, cost int) *BcryptHasher {
+	t.Helper()
+
+	hasher, err := NewBcryptHasher(cost)
+	if err != nil {
+		t.Fatalf("NewBcryptHasher: %v", err)
+	}
+	return hasher
+}
+
+func TestPasswordHasherRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+	}{
+		{name: "argon2id", hasher: newTestArgon2id(t, testArgon2Params)},
+		{name: "bcrypt", hasher: newTestBcrypt(t, bcrypt.MinCost)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if !tt.hasher.Identifies(hash) {
+				t.Errorf("Identifies(%s) = false", hash)
+			}
+			if tt.hasher.NeedsRehash(hash) {
+				t.Error("NeedsRehash of a fresh hash = true")
+			}
+			if err = tt.hasher.Verify("correct horse battery staple", hash); err != nil {
+				t.Errorf("Verify with the right password: %v", err)
+			}
+			if err = tt.hasher.Verify("correct horse battery stapler", hash); !errors.Is(err, ErrInvalidPassword) {
+				t.Errorf("Verify with a wrong password: error = %v, want %v", err, ErrInvalidPassword)
+			}
+
+			other, err := tt.hasher.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if string(other) == string(hash) {
+				t.Error("two hashes of the same password are equal, salt is not random")
+			}
+		})
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	hash, err := newTestArgon2id(t, testArgon2Params).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		params Argon2Params
+		want   bool
+	}{
+		{name: "same parameters", params: testArgon2Params},
+		{name: "more memory", params: Argon2Params{Memory: 128, Iterations: 1, Parallelism: 1}, want: true},
+		{name: "more iterations", params: Argon2Params{Memory: 64, Iterations: 2, Parallelism: 1}, want: true},
+		{name: "more parallelism", params: Argon2Params{Memory: 64, Iterations: 1, Parallelism: 2}, want: true},
+		{name: "longer key", params: Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, KeyLength: 64}, want: true},
+		{name: "longer salt", params: Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 32}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTestArgon2id(t, tt.params).NeedsRehash(hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgon2idVerifyRejectsMalformedHash(t *testing.T) {
+	hasher := newTestArgon2id(t, testArgon2Params)
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	parts := strings.Split(string(hash), "$")
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "empty", hash: ""},
+		{name: "missing key", hash: strings.Join(parts[:5], "$")},
+		{name: "other version", hash: strings.Replace(string(hash), "v=19", "v=16", 1)},
+		{name: "zero memory", hash: strings.Replace(string(hash), "m=64", "m=0", 1)},
+		{name: "invalid salt", hash: strings.Replace(string(hash), parts[4], "!"+parts[4][1:], 1)},
+		{name: "bcrypt hash", hash: "$2a$04$" + strings.Repeat("a", 53)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := hasher.Verify("password", []byte(tt.hash)); !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("Verify error = %v, want %v", err, ErrInvalidHash)
+			}
+			if !hasher.NeedsRehash([]byte(tt.hash)) {
+				t.Error("NeedsRehash of a malformed hash = false")
+			}
+		})
+	}
+}
+
+func TestBcryptHasher(t *testing.T) {
+	if _, err := NewBcryptHasher(bcrypt.MinCost - 1); err == nil {
+		t.Error("NewBcryptHasher accepted a cost below the minimum")
+	}
+
+	hasher := newTestBcrypt(t, bcrypt.MinCost)
+	if _, err := hasher.Hash(strings.Repeat("a", BcryptMaxPasswordBytes+1)); !errors.Is(err, ErrPasswordTooLong) {
+		t.Errorf("Hash of %d bytes: error = %v, want %v", BcryptMaxPasswordBytes+1, err, ErrPasswordTooLong)
+	}
+
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !newTestBcrypt(t, bcrypt.MinCost+1).NeedsRehash(hash) {
+		t.Error("NeedsRehash after a cost change = false")
+	}
+}
+
+func TestPasswordHashersMigratesLegacyHashes(t *testing.T) {
+	legacy := newTestBcrypt(t, bcrypt.MinCost)
+	hashers := NewPasswordHashers(newTestArgon2id(t, testArgon2Params), legacy)
+
+	legacyHash, err := legacy.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if err = hashers.Verify("password", legacyHash); err != nil {
+		t.Errorf("Verify of a bcrypt hash: %v", err)
+	}
+	if !hashers.NeedsRehash(legacyHash) {
+		t.Error("NeedsRehash of a bcrypt hash = false, want an upgrade to argon2id")
+	}
+
+	hash, err := hashers.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(string(hash), argon2idPrefix) {
+		t.Errorf("new hash %s is not argon2id", hash)
+	}
+	if hashers.NeedsRehash(hash) {
+		t.Error("NeedsRehash of a current hash = true")
+	}
+
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr error
+	}{
+		{name: "empty", hash: "", wantErr: ErrInvalidHash},
+		{name: "unknown algorithm", hash: "$scrypt$ln=15,r=8,p=1$c2FsdA$a2V5", wantErr: ErrUnknownHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := hashers.Verify("password", []byte(tt.hash)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package hashing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestPeppered(t *testing.T, inner PasswordHasher, current Pepper, retired ...Pepper) *PepperedHasher {
+	t.Helper()
+
+	hasher, err := NewPepperedHasher(inner, current, retired...)
+	if err != nil {
+		t.Fatalf("NewPepperedHasher: %v", err)
+	}
+	return hasher
+}
+
+func TestNewPepperedHasherValidatesPeppers(t *testing.T) {
+	inner := newTestBcrypt(t, bcrypt.MinCost)
+
+	tests := []struct {
+		name    string
+		current Pepper
+		retired []Pepper
+		wantErr bool
+	}{
+		{name: "single pepper", current: Pepper{Version: 1, Secret: []byte("one")}},
+		{name: "with retired", current: Pepper{Version: 2, Secret: []byte("two")}, retired: []Pepper{{Version: 1, Secret: []byte("one")}}},
+		{name: "empty secret", current: Pepper{Version: 1}, wantErr: true},
+		{name: "duplicate version", current: Pepper{Version: 1, Secret: []byte("two")}, retired: []Pepper{{Version: 1, Secret: []byte("one")}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPepperedHasher(inner, tt.current, tt.retired...); (err != nil) != tt.wantErr {
+				t.Errorf("NewPepperedHasher error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPepperedHasherRoundTrip(t *testing.T) {
+	inner := newTestBcrypt(t, bcrypt.MinCost)
+	hasher := newTestPeppered(t, inner, Pepper{Version: 3, Secret: []byte("pepper")})
+
+	// Longer than bcrypt accepts; the HMAC pre-hash makes it fit.
+	password := strings.Repeat("long passphrase ", 10)
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(string(hash), "$pepper$v=3$2a$") {
+		t.Errorf("hash %s has no pepper version prefix", hash)
+	}
+	if !hasher.Identifies(hash) {
+		t.Error("Identifies of a peppered hash = false")
+	}
+	if hasher.NeedsRehash(hash) {
+		t.Error("NeedsRehash of a fresh hash = true")
+	}
+	if err = hasher.Verify(password, hash); err != nil {
+		t.Errorf("Verify with the right password: %v", err)
+	}
+	if err = hasher.Verify(password+"!", hash); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("Verify with a wrong password: error = %v, want %v", err, ErrInvalidPassword)
+	}
+
+	// Without the pepper the stored hash is useless.
+	_, innerHash, _ := splitPepperedHash(hash)
+	if err = inner.Verify(password[:BcryptMaxPasswordBytes], innerHash); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("inner Verify with the raw password: error = %v, want %v", err, ErrInvalidPassword)
+	}
+	other := newTestPeppered(t, inner, Pepper{Version: 3, Secret: []byte("other pepper")})
+	if err = other.Verify(password, hash); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("Verify with another pepper: error = %v, want %v", err, ErrInvalidPassword)
+	}
+}
+
+func TestPepperedHasherRotation(t *testing.T) {
+	inner := newTestBcrypt(t, bcrypt.MinCost)
+	first := Pepper{Version: 1, Secret: []byte("first")}
+	second := Pepper{Version: 2, Secret: []byte("second")}
+
+	oldHash, err := newTestPeppered(t, inner, first).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	plainHash, err := inner.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	rotated := newTestPeppered(t, inner, second, first)
+	tests := []struct {
+		name            string
+		hash            []byte
+		wantErr         error
+		wantNeedsRehash bool
+	}{
+		{name: "retired pepper", hash: oldHash, wantNeedsRehash: true},
+		{name: "no pepper", hash: plainHash, wantNeedsRehash: true},
+		{name: "unknown pepper", hash: []byte(strings.Replace(string(oldHash), "v=1", "v=9", 1)), wantErr: ErrUnknownPepper, wantNeedsRehash: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := rotated.Verify("password", tt.hash); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+			if got := rotated.NeedsRehash(tt.hash); got != tt.wantNeedsRehash {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.wantNeedsRehash)
+			}
+		})
+	}
+
+	if err = newTestPeppered(t, inner, second).Verify("password", oldHash); !errors.Is(err, ErrUnknownPepper) {
+		t.Errorf("Verify after the retired pepper is dropped: error = %v, want %v", err, ErrUnknownPepper)
+	}
+}
+
+func TestPasswordHashersIdentifyPepperedHashes(t *testing.T) {
+	pepper := Pepper{Version: 1, Secret: []byte("pepper")}
+	legacy := newTestBcrypt(t, bcrypt.MinCost)
+	hashers := NewPasswordHashers(
+		newTestPeppered(t, newTestArgon2id(t, testArgon2Params), pepper),
+		newTestPeppered(t, legacy, pepper),
+	)
+
+	legacyHash, err := newTestPeppered(t, legacy, pepper).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if err = hashers.Verify("password", legacyHash); err != nil {
+		t.Errorf("Verify of a peppered bcrypt hash: %v", err)
+	}
+	if !hashers.NeedsRehash(legacyHash) {
+		t.Error("NeedsRehash of a peppered bcrypt hash = false, want an upgrade to argon2id")
+	}
+}
@@ -0,0 +1,40 @@
+package hashing
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+const (
+	PKCEMethodS256 = "S256"
+
+	pkceMinLength = 43
+	pkceMaxLength = 128
+)
+
+// ValidPKCEValue reports whether s is a well-formed code_verifier or S256
+// code_challenge (RFC 7636, section 4.1).
+func ValidPKCEValue(s string) bool {
+	if len(s) < pkceMinLength || len(s) > pkceMaxLength {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func VerifyPKCE(verifier string, challenge string) bool {
+	if !ValidPKCEValue(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,57 @@
+package hashing
+
+import (
+	"strings"
+	"testing"
+)
+
+// RFC 7636 appendix B.
+const (
+	rfc7636Verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfc7636Challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{name: "RFC 7636 vector", verifier: rfc7636Verifier, challenge: rfc7636Challenge, want: true},
+		{name: "wrong verifier", verifier: strings.Replace(rfc7636Verifier, "d", "e", 1), challenge: rfc7636Challenge},
+		{name: "plain method", verifier: rfc7636Verifier, challenge: rfc7636Verifier},
+		{name: "short verifier", verifier: rfc7636Verifier[:42], challenge: rfc7636Challenge},
+		{name: "invalid characters", verifier: rfc7636Verifier[:42] + "+", challenge: rfc7636Challenge},
+		{name: "empty", verifier: "", challenge: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPKCE(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("VerifyPKCE = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidPKCEValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "minimum length", value: strings.Repeat("a", 43), want: true},
+		{name: "maximum length", value: strings.Repeat("a", 128), want: true},
+		{name: "unreserved characters", value: rfc7636Verifier[:39] + "-._~", want: true},
+		{name: "too short", value: strings.Repeat("a", 42)},
+		{name: "too long", value: strings.Repeat("a", 129)},
+		{name: "padding", value: rfc7636Challenge[:42] + "="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidPKCEValue(tt.value); got != tt.want {
+				t.Errorf("ValidPKCEValue(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package hashing
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+)
+
+const recoveryCodeBytes = 10
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateRecoveryCode returns an 80-bit code formatted as XXXX-XXXX-XXXX-XXXX
+// together with the hash to store.
+func GenerateRecoveryCode() (string, []byte, error) {
+	raw := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+
+	encoded := recoveryEncoding.EncodeToString(raw)
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:i+4])
+	}
+
+	code := strings.Join(groups, "-")
+	return code, HashRecoveryCode(code), nil
+}
+
+// HashRecoveryCode ignores case, spaces and dashes so codes can be typed the
+// way users copy them.
+func HashRecoveryCode(code string) []byte {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+	return HashVerificationToken(normalized)
+}
@@ -0,0 +1,53 @@
+package hashing
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+var recoveryCodeFormat = regexp.MustCompile(`^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`)
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	code, codeHash, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCode: %v", err)
+	}
+	if !recoveryCodeFormat.MatchString(code) {
+		t.Errorf("code %q does not match XXXX-XXXX-XXXX-XXXX", code)
+	}
+	if !bytes.Equal(codeHash, HashRecoveryCode(code)) {
+		t.Error("returned hash does not match HashRecoveryCode")
+	}
+
+	other, _, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCode: %v", err)
+	}
+	if other == code {
+		t.Error("GenerateRecoveryCode returned the same code twice")
+	}
+}
+
+func TestHashRecoveryCodeNormalization(t *testing.T) {
+	want := HashRecoveryCode("ABCD-EFGH-IJKL-MNOP")
+
+	tests := []struct {
+		name  string
+		input string
+		match bool
+	}{
+		{name: "lower case", input: "abcd-efgh-ijkl-mnop", match: true},
+		{name: "without dashes", input: "ABCDEFGHIJKLMNOP", match: true},
+		{name: "spaces", input: "ABCD EFGH IJKL MNOP", match: true},
+		{name: "other code", input: "ABCD-EFGH-IJKL-MNOQ", match: false},
+		{name: "truncated", input: "ABCD-EFGH-IJKL", match: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bytes.Equal(HashRecoveryCode(tt.input), want); got != tt.match {
+				t.Errorf("HashRecoveryCode(%q) matches = %v, want %v", tt.input, got, tt.match)
+			}
+		})
+	}
+}
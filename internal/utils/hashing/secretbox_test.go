@@ -0,0 +1,82 @@
+package hashing
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box, err := NewSecretBox(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatalf("NewSecretBox: %v", err)
+	}
+
+	plaintext := []byte("totp secret")
+	sealed, err := box.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	opened, err := box.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Open = %q, want %q", opened, plaintext)
+	}
+
+	other, err := box.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Equal(sealed, other) {
+		t.Error("Seal reused a nonce")
+	}
+}
+
+func TestSecretBoxRejectsTampering(t *testing.T) {
+	box, err := NewSecretBox(bytes.Repeat([]byte{0x42}, 32))
+	if err != nil {
+		t.Fatalf("NewSecretBox: %v", err)
+	}
+	otherKey, err := NewSecretBox(bytes.Repeat([]byte{0x24}, 32))
+	if err != nil {
+		t.Fatalf("NewSecretBox: %v", err)
+	}
+
+	sealed, err := box.Seal([]byte("totp secret"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	flip := func(index int) []byte {
+		tampered := bytes.Clone(sealed)
+		tampered[index] ^= 0x01
+		return tampered
+	}
+
+	tests := []struct {
+		name       string
+		box        *SecretBox
+		ciphertext []byte
+	}{
+		{name: "nonce", box: box, ciphertext: flip(0)},
+		{name: "ciphertext", box: box, ciphertext: flip(len(sealed) / 2)},
+		{name: "tag", box: box, ciphertext: flip(len(sealed) - 1)},
+		{name: "truncated", box: box, ciphertext: sealed[:8]},
+		{name: "wrong key", box: otherKey, ciphertext: sealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.box.Open(tt.ciphertext); !errors.Is(err, ErrInvalidCiphertext) {
+				t.Errorf("Open error = %v, want %v", err, ErrInvalidCiphertext)
+			}
+		})
+	}
+}
+
+func TestNewSecretBoxKeySize(t *testing.T) {
+	if _, err := NewSecretBox(make([]byte, 16)); err == nil {
+		t.Error("NewSecretBox accepted a 16 byte key")
+	}
+}
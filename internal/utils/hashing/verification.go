@@ -0,0 +1,47 @@
+package hashing
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+)
+
+const (
+	verificationTokenBytes = 32
+	loginCodeDigits        = 6
+)
+
+func GenerateVerificationToken() (string, []byte, error) {
+	raw := make([]byte, verificationTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashVerificationToken(token), nil
+}
+
+func HashVerificationToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+func GenerateLoginCode() (string, error) {
+	limit := big.NewInt(1_000_000)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", loginCodeDigits, n.Int64()), nil
+}
+
+// HashLoginCode binds a short login code to its token row so that equal codes
+// issued to different users never produce the same token hash.
+func HashLoginCode(tokenID uuid.UUID, code string) []byte {
+	sum := sha256.Sum256(append(tokenID[:], code...))
+	return sum[:]
+}
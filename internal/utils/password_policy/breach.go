@@ -0,0 +1,129 @@
+package password_policy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BreachCheckLocal = "local"
+	BreachCheckAPI   = "api"
+
+	hashPrefixLength = 5
+)
+
+// BreachChecker reports how many times a password appears in a breach corpus,
+// looking it up by the first five hex characters of its SHA-1 hash
+// (k-anonymity) as the Pwned Passwords range API does.
+type BreachChecker interface {
+	BreachCount(ctx context.Context, password string) (int, error)
+}
+
+type LocalBreachChecker struct {
+	dir string
+}
+
+func NewLocalBreachChecker(dir string) (*LocalBreachChecker, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("breach corpus: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breach corpus %s is not a directory", dir)
+	}
+	return &LocalBreachChecker{dir: dir}, nil
+}
+
+func (c *LocalBreachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	prefix, suffix := hashPassword(password)
+
+	file, err := os.Open(filepath.Join(c.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing breach corpus file: %v", err)
+		}
+	}()
+
+	return findSuffix(file, suffix)
+}
+
+type RangeAPIBreachChecker struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewRangeAPIBreachChecker(baseURL string, timeout time.Duration) *RangeAPIBreachChecker {
+	return &RangeAPIBreachChecker{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/",
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *RangeAPIBreachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	prefix, suffix := hashPassword(password)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing breach range response: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("breach range API returned %s", resp.Status)
+	}
+
+	return findSuffix(resp.Body, suffix)
+}
+
+func hashPassword(password string) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:hashPrefixLength], hash[hashPrefixLength:]
+}
+
+func findSuffix(r io.Reader, suffix string) (int, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, count, found := strings.Cut(line, ":")
+		if !found || !strings.EqualFold(candidate, suffix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("invalid breach count %q: %w", count, err)
+		}
+		return n, nil
+	}
+
+	return 0, scanner.Err()
+}
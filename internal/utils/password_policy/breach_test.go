@@ -0,0 +1,162 @@
+package password_policy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8.
+const (
+	passwordPrefix = "5BAA6"
+	passwordSuffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+)
+
+const passwordRange = "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" +
+	"1e4c9b93f3f0682250b6cf8331b7ee68fd8:9659365\r\n" +
+	"011053FD0102E94D6AE2F8B83D76FAF94F6:0\r\n"
+
+type fakeBreachChecker struct {
+	count int
+	err   error
+}
+
+func (c fakeBreachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	return c.count, c.err
+}
+
+func TestHashPassword(t *testing.T) {
+	prefix, suffix := hashPassword("password")
+	if prefix != passwordPrefix || suffix != passwordSuffix {
+		t.Errorf("hashPassword = %s, %s, want %s, %s", prefix, suffix, passwordPrefix, passwordSuffix)
+	}
+}
+
+func TestLocalBreachChecker(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, passwordPrefix+".txt"), []byte(passwordRange), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	checker, err := NewLocalBreachChecker(dir)
+	if err != nil {
+		t.Fatalf("NewLocalBreachChecker: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     int
+	}{
+		{name: "breached", password: "password", want: 9659365},
+		{name: "missing range file", password: "correct horse battery staple"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checker.BreachCount(context.Background(), tt.password)
+			if err != nil {
+				t.Fatalf("BreachCount: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BreachCount(%q) = %d, want %d", tt.password, got, tt.want)
+			}
+		})
+	}
+
+	if _, err = NewLocalBreachChecker(filepath.Join(dir, "missing")); err == nil {
+		t.Error("NewLocalBreachChecker accepted a missing directory")
+	}
+	if _, err = NewLocalBreachChecker(filepath.Join(dir, passwordPrefix+".txt")); err == nil {
+		t.Error("NewLocalBreachChecker accepted a file")
+	}
+}
+
+func TestRangeAPIBreachChecker(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Error("request without Add-Padding header")
+		}
+		switch r.URL.Path {
+		case "/range/" + passwordPrefix:
+			_, _ = w.Write([]byte(passwordRange))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	checker := NewRangeAPIBreachChecker(server.URL+"/range/", time.Second)
+	count, err := checker.BreachCount(context.Background(), "password")
+	if err != nil {
+		t.Fatalf("BreachCount: %v", err)
+	}
+	if count != 9659365 {
+		t.Errorf("BreachCount = %d, want 9659365", count)
+	}
+
+	// Only the five character prefix of the hash leaves the server.
+	if want := []string{"/range/" + passwordPrefix}; !slices.Equal(requested, want) {
+		t.Errorf("requested %v, want %v", requested, want)
+	}
+
+	if _, err = checker.BreachCount(context.Background(), "correct horse battery staple"); err == nil {
+		t.Error("BreachCount ignored an error status")
+	}
+}
+
+func TestFindSuffix(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr bool
+	}{
+		{name: "padding entry", body: passwordSuffix + ":0\n", want: 0},
+		{name: "skips malformed lines", body: "not a range line\n" + passwordSuffix + ":3\n", want: 3},
+		{name: "invalid count", body: passwordSuffix + ":many\n", wantErr: true},
+		{name: "empty", body: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findSuffix(strings.NewReader(tt.body), passwordSuffix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findSuffix error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("findSuffix = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckBreaches(t *testing.T) {
+	tests := []struct {
+		name     string
+		checker  fakeBreachChecker
+		minCount int
+		want     []string
+	}{
+		{name: "not breached"},
+		{name: "breached", checker: fakeBreachChecker{count: 1}, want: []string{RuleBreached}},
+		{name: "below minimum count", checker: fakeBreachChecker{count: 4}, minCount: 5},
+		{name: "at minimum count", checker: fakeBreachChecker{count: 5}, minCount: 5, want: []string{RuleBreached}},
+		{name: "checker unavailable", checker: fakeBreachChecker{err: errors.New("connection refused")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewWithBreachChecker(Config{BreachMinCount: tt.minCount}, tt.checker)
+			err := policy.Check(context.Background(), "password", "")
+			if got := violatedRules(t, err); !slices.Equal(got, tt.want) {
+				t.Errorf("Check rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package password_policy
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"authService/internal/utils/service_errors"
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleUpper     = "uppercase"
+	RuleLower     = "lowercase"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleEmail     = "contains_email"
+	RuleCommon    = "common_password"
+	RuleStrength  = "strength"
+	RuleBreached  = "breached"
+	RuleReused    = "reused"
+
+	minEmailPartLength = 3
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+type Config struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	RejectEmail   bool
+	RejectCommon  bool
+	MinStrength   int
+
+	BreachCheck     string
+	BreachCorpusDir string
+	BreachAPIURL    string
+	BreachMinCount  int
+}
+
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+type Violations []Violation
+
+func (v Violations) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, violation := range v {
+		messages = append(messages, violation.Message)
+	}
+	return "password does not satisfy policy: " + strings.Join(messages, "; ")
+}
+
+func (v Violations) Is(target error) bool {
+	return target == service_errors.WeakPasswordError
+}
+
+type Policy struct {
+	cfg      Config
+	common   map[string]struct{}
+	breaches BreachChecker
+}
+
+func New(cfg Config) (*Policy, error) {
+	var breaches BreachChecker
+	switch cfg.BreachCheck {
+	case "":
+	case BreachCheckLocal:
+		checker, err := NewLocalBreachChecker(cfg.BreachCorpusDir)
+		if err != nil {
+			return nil, err
+		}
+		breaches = checker
+	case BreachCheckAPI:
+		breaches = NewRangeAPIBreachChecker(cfg.BreachAPIURL, 5*time.Second)
+	default:
+		return nil, fmt.Errorf("unsupported breach check: %s", cfg.BreachCheck)
+	}
+
+	return NewWithBreachChecker(cfg, breaches), nil
+}
+
+func NewWithBreachChecker(cfg Config, breaches BreachChecker) *Policy {
+	common := make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			common[strings.ToLower(line)] = struct{}{}
+		}
+	}
+
+	return &Policy{
+		cfg:      cfg,
+		common:   common,
+		breaches: breaches,
+	}
+}
+
+func (p *Policy) Check(ctx context.Context, password string, email string) error {
+	length := utf8.RuneCountInString(password)
+	// Oversized input is rejected before zxcvbn and the breach check, whose
+	// cost grows with the password length.
+	if p.cfg.MaxLength > 0 && length > p.cfg.MaxLength {
+		return Violations{{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("must be at most %d characters long", p.cfg.MaxLength),
+		}}
+	}
+
+	var violations Violations
+	if length < p.cfg.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("must be at least %d characters long", p.cfg.MinLength),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.cfg.RequireUpper && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUpper, Message: "must contain an uppercase letter"})
+	}
+	if p.cfg.RequireLower && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLower, Message: "must contain a lowercase letter"})
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Message: "must contain a digit"})
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Message: "must contain a symbol"})
+	}
+
+	lowered := strings.ToLower(password)
+	localPart, _, _ := strings.Cut(strings.ToLower(email), "@")
+	if p.cfg.RejectEmail && utf8.RuneCountInString(localPart) >= minEmailPartLength && strings.Contains(lowered, localPart) {
+		violations = append(violations, Violation{Rule: RuleEmail, Message: "must not contain the email address"})
+	}
+
+	if p.cfg.RejectCommon {
+		if _, found := p.common[lowered]; found {
+			violations = append(violations, Violation{Rule: RuleCommon, Message: "is too common"})
+		}
+	}
+
+	if p.cfg.MinStrength > 0 {
+		var userInputs []string
+		if localPart != "" {
+			userInputs = []string{email, localPart}
+		}
+		if strength := zxcvbn.PasswordStrength(password, userInputs); strength.Score < p.cfg.MinStrength {
+			violations = append(violations, Violation{
+				Rule:    RuleStrength,
+				Message: fmt.Sprintf("is too easy to guess (strength %d of 4, at least %d required)", strength.Score, p.cfg.MinStrength),
+			})
+		}
+	}
+
+	if p.breaches != nil {
+		count, err := p.breaches.BreachCount(ctx, password)
+		if err != nil {
+			log.Printf("Error checking password against breach corpus: %v", err)
+		} else if count > 0 && count >= p.cfg.BreachMinCount {
+			violations = append(violations, Violation{Rule: RuleBreached, Message: "has appeared in a data breach"})
+		}
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
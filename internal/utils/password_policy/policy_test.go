@@ -0,0 +1,116 @@
+package password_policy
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"authService/internal/utils/service_errors"
+)
+
+func violatedRules(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var violations Violations
+	if !errors.As(err, &violations) {
+		t.Fatalf("Check returned %v, want Violations", err)
+	}
+	if !errors.Is(err, service_errors.WeakPasswordError) {
+		t.Errorf("errors.Is(%v, WeakPasswordError) = false", err)
+	}
+
+	rules := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		rules = append(rules, violation.Rule)
+	}
+	return rules
+}
+
+func TestPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		password string
+		email    string
+		want     []string
+	}{
+		{name: "long enough", cfg: Config{MinLength: 8}, password: "abcdefgh"},
+		{name: "too short", cfg: Config{MinLength: 8}, password: "abcdefg", want: []string{RuleMinLength}},
+		{name: "length counts runes", cfg: Config{MinLength: 6, MaxLength: 6}, password: "пароль"},
+		{
+			name:     "too long skips other rules",
+			cfg:      Config{MinLength: 8, MaxLength: 10, RequireDigit: true, MinStrength: 4},
+			password: "aaaaaaaaaaa",
+			want:     []string{RuleMaxLength},
+		},
+		{
+			name:     "character classes",
+			cfg:      Config{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true},
+			password: "abc",
+			want:     []string{RuleUpper, RuleDigit, RuleSymbol},
+		},
+		{
+			name:     "space counts as symbol",
+			cfg:      Config{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true},
+			password: "Abc 1",
+		},
+		{name: "contains email", cfg: Config{RejectEmail: true}, password: "xxAlice.Smithxx", email: "alice.smith@example.com", want: []string{RuleEmail}},
+		{name: "short local part is ignored", cfg: Config{RejectEmail: true}, password: "xxalxx", email: "al@example.com"},
+		{name: "email check disabled", password: "alice.smith", email: "alice.smith@example.com"},
+		{name: "common password", cfg: Config{RejectCommon: true}, password: "QWERTY", want: []string{RuleCommon}},
+		{name: "uncommon password", cfg: Config{RejectCommon: true}, password: "vault-lantern-ostrich"},
+		{
+			name:     "several violations",
+			cfg:      Config{MinLength: 10, RequireDigit: true, RejectCommon: true},
+			password: "password",
+			want:     []string{RuleMinLength, RuleDigit, RuleCommon},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewWithBreachChecker(tt.cfg, nil).Check(context.Background(), tt.password, tt.email)
+			if got := violatedRules(t, err); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q) rules = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckStrength(t *testing.T) {
+	policy := NewWithBreachChecker(Config{MinStrength: 3}, nil)
+
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		want     []string
+	}{
+		{name: "dictionary word", password: "password1", want: []string{RuleStrength}},
+		{name: "keyboard pattern", password: "qwertyuiop", want: []string{RuleStrength}},
+		{name: "passphrase", password: "correct horse battery staple"},
+		{name: "random", password: "x7#Qm!v2Lp9@"},
+		{name: "based on the email", password: "jdoe-example.com", email: "jdoe-example.com@mail.test", want: []string{RuleStrength}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(context.Background(), tt.password, tt.email)
+			if got := violatedRules(t, err); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q) rules = %v, want %v", tt.password, got, tt.want)
+			}
+			if err != nil && !strings.Contains(err.Error(), "at least 3 required") {
+				t.Errorf("message %q does not name the required strength", err)
+			}
+		})
+	}
+}
+
+func TestNewRejectsUnknownBreachCheck(t *testing.T) {
+	if _, err := New(Config{BreachCheck: "remote"}); err == nil {
+		t.Error("New accepted an unknown breach check")
+	}
+}
@@ -3,8 +3,36 @@ package service_errors
 import "errors"
 
 var (
-	InternalServerError     = errors.New("internal server error")
-	UserAlreadyExistsError  = errors.New("user already exists")
-	UserNotFoundError       = errors.New("user not found")
-	InvalidCredentialsError = errors.New("invalid credentials")
+	InternalServerError           = errors.New("internal server error")
+	UserAlreadyExistsError        = errors.New("user already exists")
+	UserNotFoundError             = errors.New("user not found")
+	InvalidCredentialsError       = errors.New("invalid credentials")
+	InvalidTokenError             = errors.New("invalid token")
+	TokenExpiredError             = errors.New("token expired")
+	RefreshTokenReuseError        = errors.New("refresh token reuse detected")
+	TokenRevokedError             = errors.New("token revoked")
+	MissingTokenError             = errors.New("missing access token")
+	UnknownClientError            = errors.New("unknown client")
+	SessionNotFoundError          = errors.New("session not found")
+	EmailNotConfirmedError        = errors.New("email not confirmed")
+	WeakPasswordError             = errors.New("password does not satisfy policy")
+	PasswordTooLongError          = errors.New("password too long")
+	AccountLockedError            = errors.New("too many failed login attempts")
+	MFAUnavailableError           = errors.New("mfa is not configured")
+	MFAAlreadyEnabledError        = errors.New("mfa already enabled")
+	MFANotEnrolledError           = errors.New("mfa enrollment not found")
+	InvalidMFACodeError           = errors.New("invalid mfa code")
+	PasskeysUnavailableError      = errors.New("passkeys are not configured")
+	PasskeyAlreadyRegisteredError = errors.New("passkey already registered")
+	InvalidPasskeyError           = errors.New("passkey verification failed")
+	InvalidLoginCodeError         = errors.New("invalid login code")
+	OAuthClientAlreadyExistsError = errors.New("oauth client already exists")
+	InvalidOAuthClientError       = errors.New("invalid oauth client")
+	InvalidRedirectURIError       = errors.New("invalid redirect uri")
+	UnauthorizedClientError       = errors.New("client is not allowed to use this grant type")
+	InvalidGrantError             = errors.New("invalid authorization grant")
+	IntrospectionUnavailableError = errors.New("introspection is not configured")
+	InvalidCallerCredentialsError = errors.New("invalid caller credentials")
+	OAuthLoginUnavailableError    = errors.New("oauth login is not configured")
+	ConsentRequiredError          = errors.New("user consent required")
 )
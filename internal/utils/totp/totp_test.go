@@ -0,0 +1,57 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B, SHA-1 secret, truncated to six digits.
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := Code(rfc6238Secret, Step(time.Unix(tt.unix, 0))); got != tt.want {
+				t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", code: "050471", skew: 0, wantStep: current, wantOK: true},
+		{name: "previous step within skew", code: Code(rfc6238Secret, current-1), skew: 1, wantStep: current - 1, wantOK: true},
+		{name: "previous step without skew", code: Code(rfc6238Secret, current-1), skew: 0},
+		{name: "wrong code", code: "000000", skew: 1},
+		{name: "wrong length", code: "05047", skew: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfc6238Secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN email_confirmed_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE users SET email_confirmed_at = created_at WHERE is_active = TRUE;
+
+CREATE TABLE verification_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash BYTEA NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_verification_tokens_user_purpose ON verification_tokens(user_id, purpose);
+CREATE INDEX idx_verification_tokens_expires_at ON verification_tokens(expires_at);
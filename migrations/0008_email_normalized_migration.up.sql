@@ -0,0 +1,24 @@
+DO $$
+DECLARE
+    duplicates INT;
+BEGIN
+    SELECT count(*) INTO duplicates
+    FROM (
+        SELECT 1
+        FROM users
+        GROUP BY lower(normalize(btrim(email), NFC))
+        HAVING count(*) > 1
+    ) groups;
+
+    IF duplicates > 0 THEN
+        RAISE EXCEPTION '% emails are shared by several accounts, merge them before upgrading (go run ./cmd/email-dedupe)', duplicates;
+    END IF;
+END $$;
+
+ALTER TABLE users ADD COLUMN email_normalized VARCHAR(320);
+
+UPDATE users SET email_normalized = lower(normalize(btrim(email), NFC));
+
+ALTER TABLE users ALTER COLUMN email_normalized SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_users_email_normalized ON users(email_normalized);